@@ -0,0 +1,173 @@
+// Package clusterid assigns cluster IDs that stay stable across runs.
+// The root a union-find structure reports for a set changes as new edges arrive,
+// so instead new sets take over the IDs of the previous clusters they overlap most,
+// and the merges, splits, births and deaths between the runs are reported.
+package clusterid
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/maxjustus/bpuf/unionfind"
+)
+
+// ChangeKind is the kind of a change between two runs
+type ChangeKind int
+
+const (
+	// Birth is a new cluster with no values from any previous cluster
+	Birth ChangeKind = iota
+	// Death is a previous cluster with no values left in any new cluster
+	Death
+	// Merge is a new cluster with values from more than one previous cluster
+	Merge
+	// Split is a previous cluster with values in more than one new cluster
+	Split
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Birth:
+		return "birth"
+	case Death:
+		return "death"
+	case Merge:
+		return "merge"
+	case Split:
+		return "split"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is a change to the clusters between two runs
+type Change struct {
+	Kind ChangeKind
+	// IDs of the previous clusters involved, sorted
+	From []string
+	// IDs of the new clusters involved, sorted
+	To []string
+}
+
+// Cluster is a set with its assigned ID
+type Cluster[T comparable] struct {
+	ID      string
+	Members []T
+}
+
+// Assignment is the assignment of IDs to the sets of a union-find structure
+type Assignment[T comparable] struct {
+	// Clusters in the order of the sets they were assigned to
+	Clusters []Cluster[T]
+	// ID of the cluster of each value
+	IDs map[T]string
+	// Births and merges in the order of the clusters,
+	// followed by deaths and splits in the order of the previous IDs
+	Changes []Change
+}
+
+// Assign assigns an ID to every set of uf, given the ID of each value's cluster in the previous run.
+// Sets take over the IDs of the previous clusters they share values with
+// so that as many values as possible keep their previous ID in total,
+// and every previous ID is taken over by at most one set.
+// That's usually each set taking the ID it shares the most values with,
+// but when two sets overlap the same previous cluster most one of them may take
+// its next largest overlap instead, if that keeps more values in total.
+// Sets left without one get an ID from newID, which is given their members.
+// IDs newID returns that were used in the previous run or are already taken
+// get a numeric suffix, so a retired ID is never reused.
+func Assign[T comparable](
+	previous map[T]string,
+	uf *unionfind.AlgoUnionFindWithValues[T],
+	newID func(members []T) string,
+) *Assignment[T] {
+	a := &Assignment[T]{IDs: make(map[T]string)}
+
+	// Values each set shares with each previous cluster
+	var overlaps []map[string]int
+	for _, members := range uf.Sets() {
+		overlap := make(map[string]int)
+		for _, member := range members {
+			if id, ok := previous[member]; ok {
+				overlap[id]++
+			}
+		}
+
+		a.Clusters = append(a.Clusters, Cluster[T]{Members: members})
+		overlaps = append(overlaps, overlap)
+	}
+
+	taken := make(map[string]bool)
+	for _, id := range previous {
+		taken[id] = true
+	}
+
+	matched := match(overlaps)
+	for i := range a.Clusters {
+		cluster := &a.Clusters[i]
+		cluster.ID = matched[i]
+		if cluster.ID == "" {
+			cluster.ID = unique(newID(cluster.Members), taken)
+		}
+		taken[cluster.ID] = true
+
+		for _, member := range cluster.Members {
+			a.IDs[member] = cluster.ID
+		}
+	}
+
+	a.Changes = changes(a.Clusters, overlaps, previous)
+	return a
+}
+
+// unique returns id, or id with the smallest numeric suffix that makes it not taken
+func unique(id string, taken map[string]bool) string {
+	if !taken[id] {
+		return id
+	}
+
+	for n := 2; ; n++ {
+		suffixed := fmt.Sprintf("%s-%d", id, n)
+		if !taken[suffixed] {
+			return suffixed
+		}
+	}
+}
+
+func changes[T comparable](clusters []Cluster[T], overlaps []map[string]int, previous map[T]string) []Change {
+	var changes []Change
+
+	// New clusters the values of each previous cluster ended up in
+	successors := make(map[string][]string)
+	for i, cluster := range clusters {
+		from := slices.Sorted(maps.Keys(overlaps[i]))
+		switch {
+		case len(from) == 0:
+			changes = append(changes, Change{Kind: Birth, To: []string{cluster.ID}})
+		case len(from) > 1:
+			changes = append(changes, Change{Kind: Merge, From: from, To: []string{cluster.ID}})
+		}
+
+		for _, id := range from {
+			successors[id] = append(successors[id], cluster.ID)
+		}
+	}
+
+	ids := make(map[string]bool)
+	for _, id := range previous {
+		ids[id] = true
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(ids)) {
+		switch to := successors[id]; {
+		case len(to) == 0:
+			changes = append(changes, Change{Kind: Death, From: []string{id}})
+		case len(to) > 1:
+			slices.Sort(to)
+			changes = append(changes, Change{Kind: Split, From: []string{id}, To: to})
+		}
+	}
+
+	return changes
+}
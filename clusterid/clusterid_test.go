@@ -0,0 +1,162 @@
+package clusterid_test
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/maxjustus/bpuf/clusterid"
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smallest names new clusters after their smallest member
+func smallest(members []string) string {
+	return slices.MinFunc(members, strings.Compare)
+}
+
+// kept returns the number of values in clusters that kept their previous ID
+func kept(previous map[string]string, clusters []clusterid.Cluster[string]) int {
+	n := 0
+	for _, cluster := range clusters {
+		for _, member := range cluster.Members {
+			if id, ok := previous[member]; ok && id == cluster.ID {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+// bestKept returns the most values any assignment of the previous IDs not in used
+// to clusters could keep, trying every assignment
+func bestKept(previous map[string]string, clusters []clusterid.Cluster[string], used map[string]bool) int {
+	if len(clusters) == 0 {
+		return 0
+	}
+
+	shared := make(map[string]int)
+	for _, member := range clusters[0].Members {
+		if id, ok := previous[member]; ok {
+			shared[id]++
+		}
+	}
+
+	best := bestKept(previous, clusters[1:], used)
+	for id, n := range shared {
+		if used[id] {
+			continue
+		}
+
+		used[id] = true
+		best = max(best, n+bestKept(previous, clusters[1:], used))
+		used[id] = false
+	}
+
+	return best
+}
+
+func TestAssign(t *testing.T) {
+	t.Parallel()
+
+	t.Run("keeps IDs of clusters that grow", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("zed", "ip1")
+		uf.Union("alice", "ip1")
+
+		a := clusterid.Assign(map[string]string{"zed": "c1", "ip1": "c1"}, uf, smallest)
+		assert.Equal(t, map[string]string{"zed": "c1", "ip1": "c1", "alice": "c1"}, a.IDs)
+		assert.Empty(t, a.Changes)
+	})
+
+	t.Run("reports merges, splits, births and deaths", func(t *testing.T) {
+		previous := map[string]string{
+			"a": "c1", "b": "c1", "c": "c1",
+			"d": "c2",
+			"e": "c3", "f": "c3",
+			"g": "c4",
+		}
+
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("a", "b")
+		uf.Union("b", "d") // c1 and c2 merge
+		uf.Union("c", "x") // c1 splits
+		uf.Union("e", "f")
+		uf.Union("y", "z") // born
+		// g is gone, so c4 dies
+
+		a := clusterid.Assign(previous, uf, smallest)
+		require.Len(t, a.Clusters, 4)
+		assert.Equal(t, clusterid.Cluster[string]{ID: "c1", Members: []string{"a", "b", "d"}}, a.Clusters[0])
+		assert.Equal(t, "c", a.IDs["x"], "c1 went to the cluster sharing more of its values")
+		assert.Equal(t, "c3", a.IDs["e"])
+		assert.Equal(t, "y", a.IDs["z"])
+
+		assert.Equal(t, []clusterid.Change{
+			{Kind: clusterid.Merge, From: []string{"c1", "c2"}, To: []string{"c1"}},
+			{Kind: clusterid.Birth, To: []string{"y"}},
+			{Kind: clusterid.Split, From: []string{"c1"}, To: []string{"c", "c1"}},
+			{Kind: clusterid.Death, From: []string{"c4"}},
+		}, a.Changes)
+		assert.Equal(t, "merge", a.Changes[0].Kind.String())
+	})
+
+	t.Run("keeps the most values under their previous ID in total", func(t *testing.T) {
+		previous := map[string]string{
+			"a1": "c1", "a2": "c1", "a3": "c1", "a4": "c2", "a5": "c2",
+			"b1": "c1", "b2": "c1",
+		}
+
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.UnionAll([][2]string{{"a1", "a2"}, {"a2", "a3"}, {"a3", "a4"}, {"a4", "a5"}, {"b1", "b2"}}, 1)
+
+		// Taking the largest overlap first would give c1 to the a's and nothing to the b's,
+		// keeping 3 values rather than 4
+		a := clusterid.Assign(previous, uf, smallest)
+		assert.Equal(t, "c2", a.IDs["a1"])
+		assert.Equal(t, "c1", a.IDs["b1"])
+	})
+
+	t.Run("matches the best assignment found by brute force", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		for range 200 {
+			previous := make(map[string]string)
+			uf := unionfind.NewUnionFindWithValues[string](0)
+			for i := range 12 {
+				value := strconv.Itoa(i)
+				uf.Union(value, "set"+strconv.Itoa(rng.Intn(4)))
+				previous[value] = "c" + strconv.Itoa(rng.Intn(4))
+			}
+
+			a := clusterid.Assign(previous, uf, smallest)
+			require.Equal(t, bestKept(previous, a.Clusters, make(map[string]bool)), kept(previous, a.Clusters))
+		}
+	})
+
+	t.Run("new IDs never reuse taken ones", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("a", "b")
+		uf.Union("a2", "c")
+		uf.Find("z")
+
+		// c1 is retired and a new cluster would be named after a previous ID
+		a := clusterid.Assign(map[string]string{"q": "c1", "r": "a"}, uf, func([]string) string { return "a" })
+		assert.Equal(t, "a-2", a.IDs["a"])
+		assert.Equal(t, "a-3", a.IDs["c"])
+		assert.Equal(t, "a-4", a.IDs["z"])
+	})
+
+	t.Run("no previous run", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("b", "a")
+
+		a := clusterid.Assign(nil, uf, smallest)
+		assert.Equal(t, map[string]string{"a": "a", "b": "a"}, a.IDs)
+		assert.Equal(t, []clusterid.Change{{Kind: clusterid.Birth, To: []string{"a"}}}, a.Changes)
+	})
+}
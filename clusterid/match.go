@@ -0,0 +1,180 @@
+package clusterid
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/maxjustus/bpuf/unionfind"
+)
+
+// match returns the previous ID each set takes over, or "" for none,
+// keeping as many values as possible under their previous ID in total.
+// It's a maximum-weight matching of sets to previous IDs, weighted by the values they share.
+//
+// Sets are first matched greedily, taking the pairs with the most values in common first.
+// That's optimal for every group of sets linked by the previous IDs they overlap
+// whose sets all get their largest overlap, which is the common case of clusters
+// that grow or shrink a little between runs. The rest of the groups are matched
+// with the Hungarian algorithm instead, which takes time cubic in the size of the group.
+func match(overlaps []map[string]int) []string {
+	matched := greedy(overlaps)
+
+	// Groups of sets linked by the previous IDs they overlap, which can be matched separately
+	groups := unionfind.NewUnionFind(len(overlaps))
+	first := make(map[string]int)
+	for set, overlap := range overlaps {
+		groups.Find(set)
+		for id := range overlap {
+			if other, ok := first[id]; ok {
+				groups.Union(set, other)
+			} else {
+				first[id] = set
+			}
+		}
+	}
+
+	for _, sets := range groups.Sets() {
+		kept := 0
+		for _, set := range sets {
+			kept += overlaps[set][matched[set]]
+		}
+
+		if kept < largestOverlaps(overlaps, sets) {
+			for i, id := range hungarian(overlaps, sets) {
+				matched[sets[i]] = id
+			}
+		}
+	}
+
+	return matched
+}
+
+// largestOverlaps returns the sum of the largest overlap of each of sets,
+// which no matching can keep more values than
+func largestOverlaps(overlaps []map[string]int, sets []int) int {
+	total := 0
+	for _, set := range sets {
+		largest := 0
+		for _, shared := range overlaps[set] {
+			largest = max(largest, shared)
+		}
+		total += largest
+	}
+
+	return total
+}
+
+// greedy matches sets to previous IDs taking the pairs with the most values in common first
+func greedy(overlaps []map[string]int) []string {
+	type candidate struct {
+		set    int
+		id     string
+		shared int
+	}
+	var candidates []candidate
+	for set, overlap := range overlaps {
+		for id, shared := range overlap {
+			candidates = append(candidates, candidate{set: set, id: id, shared: shared})
+		}
+	}
+	slices.SortFunc(candidates, func(x, y candidate) int {
+		return cmp.Or(cmp.Compare(y.shared, x.shared), cmp.Compare(x.id, y.id), cmp.Compare(x.set, y.set))
+	})
+
+	matched := make([]string, len(overlaps))
+	inherited := make(map[string]bool)
+	for _, c := range candidates {
+		if matched[c.set] == "" && !inherited[c.id] {
+			matched[c.set] = c.id
+			inherited[c.id] = true
+		}
+	}
+
+	return matched
+}
+
+// hungarian returns the previous ID matched to each of sets, or "" for none,
+// by a maximum-weight matching found with the Hungarian algorithm
+func hungarian(overlaps []map[string]int, sets []int) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, set := range sets {
+		for id := range overlaps[set] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	slices.Sort(ids)
+
+	// Rows are sets and columns are IDs, padded with columns for no ID
+	// so there are at least as many columns as rows.
+	// Costs are negated overlaps, since the algorithm minimizes.
+	n, m := len(sets), max(len(ids), len(sets))
+	cost := func(row, col int) int {
+		if col >= len(ids) {
+			return 0
+		}
+		return -overlaps[sets[row]][ids[col]]
+	}
+
+	// Potentials of the rows and columns, and the row matched to each column,
+	// all 1-based with column 0 standing for the row being added
+	u := make([]int, n+1)
+	v := make([]int, m+1)
+	row := make([]int, m+1)
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		row[0] = i
+		col := 0
+		minv := make([]int, m+1)
+		for j := range minv {
+			minv[j] = math.MaxInt
+		}
+		used := make([]bool, m+1)
+
+		for row[col] != 0 {
+			used[col] = true
+			i0, delta, next := row[col], math.MaxInt, 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if reduced := cost(i0-1, j-1) - u[i0] - v[j]; reduced < minv[j] {
+					minv[j] = reduced
+					way[j] = col
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					next = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[row[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			col = next
+		}
+
+		for col != 0 {
+			prev := way[col]
+			row[col] = row[prev]
+			col = prev
+		}
+	}
+
+	matched := make([]string, n)
+	for j := 1; j <= len(ids); j++ {
+		if set := row[j] - 1; set >= 0 && overlaps[sets[set]][ids[j-1]] > 0 {
+			matched[set] = ids[j-1]
+		}
+	}
+
+	return matched
+}
@@ -14,7 +14,11 @@ var combinedUDFXML string
 
 func main() {
 	var (
-		mode     = flag.String("mode", "unionfind", "UDF mode: 'unionfind' or 'bipartite'")
+		mode       = flag.String("mode", "unionfind", "UDF mode: 'unionfind', 'bipartite', 'explain', 'timeline', 'linkage', 'aggregate' or 'clusterid'")
+		rootPolicy = flag.String("root-policy", rootPolicyRank,
+			"Which member is reported as the root of each set: 'rank', 'min' (smallest value) or 'first-seen'")
+		aggregate = flag.String("aggregate", "sum",
+			"Aggregate of each set for the aggregate mode: 'count' (set size), or the 'sum', 'min' or 'max' of its numbers")
 		printXML = flag.Bool("udf-xml", false, "Print ClickHouse UDF XML configuration for both modes")
 	)
 	flag.Parse()
@@ -24,12 +28,37 @@ func main() {
 		return
 	}
 
+	if !validRootPolicy(*rootPolicy) {
+		fmt.Fprintf(os.Stderr, "Unknown root policy: %s\n", *rootPolicy)
+		os.Exit(1)
+	}
+
+	if !validAggregate(*aggregate) {
+		fmt.Fprintf(os.Stderr, "Unknown aggregate: %s\n", *aggregate)
+		os.Exit(1)
+	}
+
 	switch *mode {
 	case "unionfind":
-		cmd := &UnionFindCmd{}
+		cmd := &UnionFindCmd{RootPolicy: *rootPolicy}
 		cmd.Run()
 	case "bipartite":
-		cmd := &BipartiteUnionFindCmd{}
+		cmd := &BipartiteUnionFindCmd{RootPolicy: *rootPolicy}
+		cmd.Run()
+	case "explain":
+		cmd := &ExplainCmd{}
+		cmd.Run()
+	case "timeline":
+		cmd := &TimelineCmd{}
+		cmd.Run()
+	case "linkage":
+		cmd := &LinkageCmd{}
+		cmd.Run()
+	case "aggregate":
+		cmd := &AggregateCmd{RootPolicy: *rootPolicy, Aggregate: *aggregate}
+		cmd.Run()
+	case "clusterid":
+		cmd := &ClusterIDCmd{}
 		cmd.Run()
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown mode: %s\n", *mode)
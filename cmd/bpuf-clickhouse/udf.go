@@ -6,11 +6,49 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
 
+	"github.com/maxjustus/bpuf/clusterid"
+	"github.com/maxjustus/bpuf/linkage"
+	"github.com/maxjustus/bpuf/mst"
 	"github.com/maxjustus/bpuf/unionfind"
 )
 
-type UnionFindCmd struct{}
+// Root policies selectable with the --root-policy flag
+const (
+	rootPolicyRank      = "rank"
+	rootPolicyMin       = "min"
+	rootPolicyFirstSeen = "first-seen"
+)
+
+func validRootPolicy(policy string) bool {
+	switch policy {
+	case rootPolicyRank, rootPolicyMin, rootPolicyFirstSeen:
+		return true
+	default:
+		return false
+	}
+}
+
+type rootPolicySetter interface {
+	SetRootPolicy(policy unionfind.RootPolicy)
+	SetRootCmp(cmp func(a, b string) int)
+}
+
+// applyRootPolicy makes uf report roots according to the named policy
+func applyRootPolicy(uf rootPolicySetter, policy string) {
+	switch policy {
+	case rootPolicyMin:
+		uf.SetRootCmp(strings.Compare)
+	case rootPolicyFirstSeen:
+		uf.SetRootPolicy(unionfind.FirstSeenRoot)
+	}
+}
+
+type UnionFindCmd struct {
+	RootPolicy string
+}
 
 type UnionFindPair struct {
 	A string `json:"a"`
@@ -22,7 +60,99 @@ type UnionFindResult struct {
 	Root  string `json:"root"`
 }
 
-type BipartiteUnionFindCmd struct{}
+type BipartiteUnionFindCmd struct {
+	RootPolicy string
+}
+
+// ExplainCmd outputs the chain of edges connecting a pair of values
+type ExplainCmd struct{}
+
+// TimelineCmd answers the queries in a timeline of edges being added and removed
+type TimelineCmd struct{}
+
+type TimelineAnswer struct {
+	// 1-based position of the query in the events, as ClickHouse indexes arrays
+	Event      int  `json:"event"`
+	Connected  bool `json:"connected"`
+	Components int  `json:"components"`
+}
+
+// LinkageCmd cuts a single-linkage clustering of scored edges at each of the given thresholds
+type LinkageCmd struct{}
+
+// ScoredEdge is an edge with a number, such as a similarity score,
+// passed by ClickHouse as an [a, b, score] tuple
+type ScoredEdge struct {
+	A     string
+	B     string
+	Score float64
+}
+
+func (e *ScoredEdge) UnmarshalJSON(data []byte) error {
+	var tuple [3]json.RawMessage
+	if err := json.Unmarshal(data, &tuple); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(tuple[0], &e.A); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(tuple[1], &e.B); err != nil {
+		return err
+	}
+	return json.Unmarshal(tuple[2], &e.Score)
+}
+
+type LinkageResult struct {
+	Threshold float64 `json:"threshold"`
+	Value     string  `json:"value"`
+	Cluster   string  `json:"cluster"`
+}
+
+// AggregateCmd outputs the aggregate of the numbers of every edge in each value's set
+type AggregateCmd struct {
+	RootPolicy string
+	// One of the aggregates in aggregateMerges
+	Aggregate string
+}
+
+type AggregateResult struct {
+	Value     string  `json:"value"`
+	Root      string  `json:"root"`
+	Aggregate float64 `json:"aggregate"`
+}
+
+// aggregateMerges maps the aggregates selectable with the --aggregate flag to their merge functions.
+// Counts are sums of one per value, the size of each set.
+var aggregateMerges = map[string]func(a, b float64) float64{
+	"count": func(a, b float64) float64 { return a + b },
+	"sum":   func(a, b float64) float64 { return a + b },
+	"min":   func(a, b float64) float64 { return min(a, b) },
+	"max":   func(a, b float64) float64 { return max(a, b) },
+}
+
+func validAggregate(aggregate string) bool {
+	_, ok := aggregateMerges[aggregate]
+	return ok
+}
+
+// ClusterIDCmd assigns cluster IDs that stay stable across runs given the previous run's IDs
+type ClusterIDCmd struct{}
+
+type ClusterIDAssignment struct {
+	Value     string `json:"value"`
+	ClusterID string `json:"cluster_id"`
+}
+
+type ClusterIDChange struct {
+	Kind string   `json:"kind"`
+	From []string `json:"from"`
+	To   []string `json:"to"`
+}
+
+type ClusterIDResult struct {
+	IDs     []ClusterIDAssignment `json:"ids"`
+	Changes []ClusterIDChange     `json:"changes"`
+}
 
 type BipartiteRelation struct {
 	U string `json:"u"`
@@ -91,8 +221,10 @@ func processLines(processor func(line string) (interface{}, error)) {
 func (c *UnionFindCmd) Run() {
 	processLines(func(line string) (interface{}, error) {
 		// Parse input from ClickHouse: {"edges":[["a","b"],["c","d"]]}
+		// with an optional "max_size" capping the size of each set
 		var input struct {
-			Edges [][2]string `json:"edges"`
+			Edges   [][2]string `json:"edges"`
+			MaxSize int         `json:"max_size"`
 		}
 		if err := json.Unmarshal([]byte(line), &input); err != nil {
 			return nil, fmt.Errorf("could not parse input: %v", err)
@@ -106,6 +238,8 @@ func (c *UnionFindCmd) Run() {
 
 		// Build union-find structure
 		uf := unionfind.NewUnionFindWithValues[string](len(pairs) * 2)
+		applyRootPolicy(uf, c.RootPolicy)
+		uf.SetMaxSetSize(input.MaxSize)
 		for _, pair := range pairs {
 			uf.Union(pair.A, pair.B)
 		}
@@ -134,8 +268,10 @@ func (c *UnionFindCmd) Run() {
 func (c *BipartiteUnionFindCmd) Run() {
 	processLines(func(line string) (interface{}, error) {
 		// Parse input from ClickHouse: {"relations":[["u","v"],["x","y"]]}
+		// with an optional "max_size" capping the number of Vs in each set
 		var input struct {
 			Relations [][2]string `json:"relations"`
+			MaxSize   int         `json:"max_size"`
 		}
 		if err := json.Unmarshal([]byte(line), &input); err != nil {
 			return nil, fmt.Errorf("could not parse input: %v", err)
@@ -149,30 +285,224 @@ func (c *BipartiteUnionFindCmd) Run() {
 
 		// Build bipartite union-find structure
 		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](len(relations) * 2)
+		applyRootPolicy(buf, c.RootPolicy)
+		buf.SetMaxSetSize(input.MaxSize)
 		for _, relation := range relations {
 			buf.Union(relation.U, relation.V)
 		}
 
-		// Collect all unique U values
-		uSet := make(map[string]bool)
-		for _, relation := range relations {
-			uSet[relation.U] = true
+		// Every U in the batch belongs to exactly one cluster
+		results := make([]BipartiteResult, 0, len(relations))
+		for cluster := range buf.Clusters() {
+			for _, u := range cluster.Us {
+				results = append(results, BipartiteResult{
+					U:     u,
+					VRoot: cluster.Root,
+				})
+			}
 		}
 
-		// Build array of results
-		results := make([]BipartiteResult, 0, len(uSet))
-		for u := range uSet {
-			vRoot, exists := buf.FindVRootForU(u)
-			if !exists {
-				// This shouldn't happen, but handle gracefully
-				continue
+		return results, nil
+	})
+}
+
+func (c *ExplainCmd) Run() {
+	processLines(func(line string) (interface{}, error) {
+		// Parse input from ClickHouse: {"edges":[["a","b"],["c","d"]],"from":"a","to":"d"}
+		var input struct {
+			Edges [][2]string `json:"edges"`
+			From  string      `json:"from"`
+			To    string      `json:"to"`
+		}
+		if err := json.Unmarshal([]byte(line), &input); err != nil {
+			return nil, fmt.Errorf("could not parse input: %v", err)
+		}
+
+		uf := unionfind.NewUnionFindWithValues[string](len(input.Edges) * 2)
+		uf.EnableProvenance()
+		for _, edge := range input.Edges {
+			uf.Union(edge[0], edge[1])
+		}
+
+		// Values that aren't connected have an empty path
+		path, _ := uf.Explain(input.From, input.To)
+		results := make([]UnionFindPair, len(path))
+		for i, edge := range path {
+			results[i] = UnionFindPair{A: edge[0], B: edge[1]}
+		}
+
+		return results, nil
+	})
+}
+
+// timelineEventKinds maps the operations accepted by TimelineCmd to event kinds
+var timelineEventKinds = map[string]unionfind.EventKind{
+	"add":        unionfind.AddEdgeEvent,
+	"remove":     unionfind.RemoveEdgeEvent,
+	"connected":  unionfind.ConnectedEvent,
+	"components": unionfind.ComponentsEvent,
+}
+
+func (c *TimelineCmd) Run() {
+	processLines(func(line string) (interface{}, error) {
+		// Parse input from ClickHouse: {"events":[["add","a","b"],["remove","a","b"],["connected","a","b"],["components","",""]]}
+		var input struct {
+			Events [][3]string `json:"events"`
+		}
+		if err := json.Unmarshal([]byte(line), &input); err != nil {
+			return nil, fmt.Errorf("could not parse input: %v", err)
+		}
+
+		events := make([]unionfind.ConnectivityEvent[string], len(input.Events))
+		for i, event := range input.Events {
+			kind, ok := timelineEventKinds[event[0]]
+			if !ok {
+				return nil, fmt.Errorf("unknown event operation: %q", event[0])
+			}
+			events[i] = unionfind.ConnectivityEvent[string]{Kind: kind, A: event[1], B: event[2]}
+		}
+
+		answers := unionfind.SolveConnectivity(events)
+		results := make([]TimelineAnswer, len(answers))
+		for i, answer := range answers {
+			results[i] = TimelineAnswer{
+				Event:      answer.Event + 1,
+				Connected:  answer.Connected,
+				Components: answer.Components,
 			}
-			results = append(results, BipartiteResult{
-				U:     u,
-				VRoot: vRoot,
-			})
 		}
 
 		return results, nil
 	})
 }
+
+func (c *LinkageCmd) Run() {
+	processLines(func(line string) (interface{}, error) {
+		// Parse input from ClickHouse: {"edges":[["a","b",0.9],["b","c",0.7]],"thresholds":[0.9,0.8]}
+		var input struct {
+			Edges      []ScoredEdge `json:"edges"`
+			Thresholds []float64    `json:"thresholds"`
+		}
+		if err := json.Unmarshal([]byte(line), &input); err != nil {
+			return nil, fmt.Errorf("could not parse input: %v", err)
+		}
+
+		edges := make([]mst.Edge[string, float64], len(input.Edges))
+		for i, edge := range input.Edges {
+			edges[i] = mst.Edge[string, float64]{From: edge.A, To: edge.B, Weight: edge.Score}
+		}
+		dendrogram, err := linkage.Cluster(edges)
+		if err != nil {
+			return nil, err
+		}
+
+		// Each value with the first value of its cluster, for every threshold
+		results := make([]LinkageResult, 0, len(input.Thresholds)*len(dendrogram.Values))
+		for _, threshold := range input.Thresholds {
+			for _, cluster := range dendrogram.ClustersAt(threshold) {
+				for _, value := range cluster {
+					results = append(results, LinkageResult{Threshold: threshold, Value: value, Cluster: cluster[0]})
+				}
+			}
+		}
+
+		return results, nil
+	})
+}
+
+func (c *AggregateCmd) Run() {
+	merge := aggregateMerges[c.Aggregate]
+	processLines(func(line string) (interface{}, error) {
+		// Parse input from ClickHouse: {"edges":[["a","b",12.5],["b","c",3]]}
+		// with an optional "max_size" capping the size of each set
+		var input struct {
+			Edges   []ScoredEdge `json:"edges"`
+			MaxSize int          `json:"max_size"`
+		}
+		if err := json.Unmarshal([]byte(line), &input); err != nil {
+			return nil, fmt.Errorf("could not parse input: %v", err)
+		}
+
+		uf := unionfind.NewAggregatingUnionFindWithValues[string](len(input.Edges)*2, merge)
+		applyRootPolicy(uf, c.RootPolicy)
+		uf.SetMaxSetSize(input.MaxSize)
+
+		var values []string
+		seen := make(map[string]bool)
+		for _, edge := range input.Edges {
+			for _, value := range []string{edge.A, edge.B} {
+				if !seen[value] {
+					seen[value] = true
+					values = append(values, value)
+					if c.Aggregate == "count" {
+						uf.Add(value, 1)
+					}
+				}
+			}
+
+			// The score belongs to the merged set, so edges whose union was rejected are left out
+			if _, ok := uf.Union(edge.A, edge.B); ok && c.Aggregate != "count" {
+				uf.Add(edge.A, edge.Score)
+			}
+		}
+
+		results := make([]AggregateResult, len(values))
+		for i, value := range values {
+			aggregate, _ := uf.Aggregate(value)
+			results[i] = AggregateResult{
+				Value:     value,
+				Root:      uf.FindReturningValue(value),
+				Aggregate: aggregate,
+			}
+		}
+
+		return results, nil
+	})
+}
+
+func (c *ClusterIDCmd) Run() {
+	processLines(func(line string) (interface{}, error) {
+		// Parse input from ClickHouse: {"edges":[["a","b"],["c","d"]],"previous":[["a","c1"],["c","c2"]]}
+		var input struct {
+			Edges    [][2]string `json:"edges"`
+			Previous [][2]string `json:"previous"`
+		}
+		if err := json.Unmarshal([]byte(line), &input); err != nil {
+			return nil, fmt.Errorf("could not parse input: %v", err)
+		}
+
+		uf := unionfind.NewUnionFindWithValues[string](len(input.Edges) * 2)
+		for _, edge := range input.Edges {
+			uf.Union(edge[0], edge[1])
+		}
+
+		previous := make(map[string]string, len(input.Previous))
+		for _, assignment := range input.Previous {
+			previous[assignment[0]] = assignment[1]
+		}
+
+		// New clusters are named after their smallest value so reruns name them the same
+		assignment := clusterid.Assign(previous, uf, func(members []string) string {
+			return slices.MinFunc(members, strings.Compare)
+		})
+
+		result := ClusterIDResult{
+			IDs:     make([]ClusterIDAssignment, 0, len(assignment.IDs)),
+			Changes: make([]ClusterIDChange, len(assignment.Changes)),
+		}
+		for _, cluster := range assignment.Clusters {
+			for _, member := range cluster.Members {
+				result.IDs = append(result.IDs, ClusterIDAssignment{Value: member, ClusterID: cluster.ID})
+			}
+		}
+		for i, change := range assignment.Changes {
+			result.Changes[i] = ClusterIDChange{
+				Kind: change.Kind.String(),
+				From: append([]string{}, change.From...),
+				To:   append([]string{}, change.To...),
+			}
+		}
+
+		return result, nil
+	})
+}
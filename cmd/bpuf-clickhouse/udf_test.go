@@ -169,3 +169,204 @@ func TestEmptyInput(t *testing.T) {
 	// Should have no output for empty input
 	assert.Empty(t, output)
 }
+
+// runCmd runs cmd with input on stdin and returns what it wrote to stdout
+func runCmd(t *testing.T, cmd interface{ Run() }, input string) string {
+	t.Helper()
+
+	stdinR, stdinW, _ := os.Pipe()
+	stdoutR, stdoutW, _ := os.Pipe()
+
+	oldStdin := os.Stdin
+	oldStdout := os.Stdout
+	os.Stdin = stdinR
+	os.Stdout = stdoutW
+
+	go func() {
+		defer func() { _ = stdinW.Close() }()
+		_, _ = stdinW.WriteString(input)
+	}()
+
+	cmd.Run()
+
+	_ = stdoutW.Close()
+	os.Stdin = oldStdin
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(stdoutR)
+	return strings.TrimSpace(buf.String())
+}
+
+func TestRootPolicy(t *testing.T) {
+	// The same edges in a different order, as ClickHouse may pass them across parts
+	inputs := []string{
+		`{"edges":[["user3","user2"],["user2","user1"],["user5","user4"]]}`,
+		`{"edges":[["user4","user5"],["user1","user2"],["user2","user3"]]}`,
+	}
+
+	for _, input := range inputs {
+		output := runCmd(t, &UnionFindCmd{RootPolicy: rootPolicyMin}, input)
+
+		var wrappedResult struct {
+			Result []UnionFindResult `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(output), &wrappedResult))
+		require.Len(t, wrappedResult.Result, 5)
+
+		for _, result := range wrappedResult.Result {
+			if result.Value == "user4" || result.Value == "user5" {
+				assert.Equal(t, "user4", result.Root)
+			} else {
+				assert.Equal(t, "user1", result.Root)
+			}
+		}
+	}
+
+	output := runCmd(t, &BipartiteUnionFindCmd{RootPolicy: rootPolicyMin},
+		`{"relations":[["entity1","group101"],["entity1","group100"],["entity2","group101"]]}`)
+	assert.Contains(t, output, `{"u":"entity1","v_root":"group100"}`)
+	assert.Contains(t, output, `{"u":"entity2","v_root":"group100"}`)
+}
+
+func TestMaxSize(t *testing.T) {
+	output := runCmd(t, &UnionFindCmd{},
+		`{"edges":[["user1","ip1"],["user2","ip1"],["user3","ip1"]],"max_size":3}`)
+
+	var wrappedResult struct {
+		Result []UnionFindResult `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &wrappedResult))
+
+	roots := make(map[string]string)
+	for _, result := range wrappedResult.Result {
+		roots[result.Value] = result.Root
+	}
+	assert.Equal(t, roots["user1"], roots["user2"])
+	assert.NotEqual(t, roots["user1"], roots["user3"])
+	assert.Equal(t, "user3", roots["user3"])
+
+	output = runCmd(t, &BipartiteUnionFindCmd{},
+		`{"relations":[["ip1","user1"],["ip1","user2"],["ip2","user3"],["ip2","user2"]],"max_size":2}`)
+	assert.Contains(t, output, `{"u":"ip2","v_root":"user3"}`)
+}
+
+func TestExplainCmd(t *testing.T) {
+	output := runCmd(t, &ExplainCmd{},
+		`{"edges":[["alice","ip1"],["mallory","ip2"],["bob","ip1"],["ip2","bob"]],"from":"alice","to":"mallory"}
+{"edges":[["alice","ip1"],["mallory","ip2"]],"from":"alice","to":"mallory"}`)
+
+	lines := strings.Split(output, "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t,
+		`{"result":[{"a":"alice","b":"ip1"},{"a":"bob","b":"ip1"},{"a":"ip2","b":"bob"},{"a":"mallory","b":"ip2"}]}`,
+		lines[0])
+	assert.JSONEq(t, `{"result":[]}`, lines[1])
+}
+
+func TestTimelineCmd(t *testing.T) {
+	output := runCmd(t, &TimelineCmd{},
+		`{"events":[["add","alice","ip1"],["add","bob","ip1"],["connected","alice","bob"],["remove","ip1","bob"],["connected","alice","bob"],["components","",""]]}
+{"events":[["merge","alice","bob"]]}`)
+
+	lines := strings.Split(output, "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"result":[
+		{"event":3,"connected":true,"components":1},
+		{"event":5,"connected":false,"components":2},
+		{"event":6,"connected":false,"components":2}
+	]}`, lines[0])
+	assert.Contains(t, lines[1], "ERROR")
+}
+
+func TestLinkageCmd(t *testing.T) {
+	output := runCmd(t, &LinkageCmd{},
+		`{"edges":[["alice","alicia",0.95],["bob","rob",0.85],["alicia","bob",0.7]],"thresholds":[0.9,0.7]}
+{"edges":[["alice","alicia"]],"thresholds":[0.9]}`)
+
+	lines := strings.Split(output, "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"result":[
+		{"threshold":0.9,"value":"alice","cluster":"alice"},
+		{"threshold":0.9,"value":"alicia","cluster":"alice"},
+		{"threshold":0.9,"value":"bob","cluster":"bob"},
+		{"threshold":0.9,"value":"rob","cluster":"rob"},
+		{"threshold":0.7,"value":"alice","cluster":"alice"},
+		{"threshold":0.7,"value":"alicia","cluster":"alice"},
+		{"threshold":0.7,"value":"bob","cluster":"alice"},
+		{"threshold":0.7,"value":"rob","cluster":"alice"}
+	]}`, lines[0])
+	assert.Contains(t, lines[1], "ERROR")
+}
+
+func TestAggregateCmd(t *testing.T) {
+	input := `{"edges":[["user1","ip1",10],["user2","ip1",2.5],["user3","ip2",7]]}`
+	expected := map[string][2]float64{
+		"count": {3, 2},
+		"sum":   {12.5, 7},
+		"min":   {2.5, 7},
+		"max":   {10, 7},
+	}
+
+	for aggregate, want := range expected {
+		t.Run(aggregate, func(t *testing.T) {
+			output := runCmd(t, &AggregateCmd{RootPolicy: rootPolicyFirstSeen, Aggregate: aggregate}, input)
+
+			var result struct {
+				Result []AggregateResult `json:"result"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(output), &result))
+			assert.Equal(t, []AggregateResult{
+				{Value: "user1", Root: "user1", Aggregate: want[0]},
+				{Value: "ip1", Root: "user1", Aggregate: want[0]},
+				{Value: "user2", Root: "user1", Aggregate: want[0]},
+				{Value: "user3", Root: "user3", Aggregate: want[1]},
+				{Value: "ip2", Root: "user3", Aggregate: want[1]},
+			}, result.Result)
+		})
+	}
+}
+
+func TestAggregateCmdMaxSize(t *testing.T) {
+	input := `{"edges":[["user1","ip1",10],["user2","ip1",2.5],["user3","ip1",7]],"max_size":3}`
+	expected := map[string][2]float64{
+		"count": {3, 1},
+		"sum":   {12.5, 0},
+	}
+
+	for aggregate, want := range expected {
+		t.Run(aggregate, func(t *testing.T) {
+			output := runCmd(t, &AggregateCmd{RootPolicy: rootPolicyFirstSeen, Aggregate: aggregate}, input)
+
+			var result struct {
+				Result []AggregateResult `json:"result"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(output), &result))
+			assert.Equal(t, []AggregateResult{
+				{Value: "user1", Root: "user1", Aggregate: want[0]},
+				{Value: "ip1", Root: "user1", Aggregate: want[0]},
+				{Value: "user2", Root: "user1", Aggregate: want[0]},
+				{Value: "user3", Root: "user3", Aggregate: want[1]},
+			}, result.Result, "the rejected edge adds nothing to either set")
+		})
+	}
+}
+
+func TestClusterIDCmd(t *testing.T) {
+	output := runCmd(t, &ClusterIDCmd{},
+		`{"edges":[["user1","ip1"],["user2","ip1"],["user9","ip9"]],"previous":[["user1","c1"],["ip1","c1"],["user3","c2"]]}`)
+
+	assert.JSONEq(t, `{"result":{
+		"ids":[
+			{"value":"user1","cluster_id":"c1"},
+			{"value":"ip1","cluster_id":"c1"},
+			{"value":"user2","cluster_id":"c1"},
+			{"value":"user9","cluster_id":"ip9"},
+			{"value":"ip9","cluster_id":"ip9"}
+		],
+		"changes":[
+			{"kind":"birth","from":[],"to":["ip9"]},
+			{"kind":"death","from":["c2"],"to":[]}
+		]
+	}}`, output)
+}
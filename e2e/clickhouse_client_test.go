@@ -81,6 +81,20 @@ func TestXMLGeneration(t *testing.T) {
 	// Should contain both UDF definitions
 	assert.Contains(t, xmlStr, `<name>unionFind</name>`)
 	assert.Contains(t, xmlStr, `<name>bipartiteUnionFind</name>`)
+	assert.Contains(t, xmlStr, `<name>unionFindCapped</name>`)
+	assert.Contains(t, xmlStr, `<name>bipartiteUnionFindCapped</name>`)
+	assert.Contains(t, xmlStr, `<name>explainUnionFind</name>`)
+	assert.Contains(t, xmlStr, `--mode=explain`)
+	assert.Contains(t, xmlStr, `<name>connectivityTimeline</name>`)
+	assert.Contains(t, xmlStr, `--mode=timeline`)
+	assert.Contains(t, xmlStr, `<name>singleLinkage</name>`)
+	assert.Contains(t, xmlStr, `--mode=linkage`)
+	for _, name := range []string{"unionFindCount", "unionFindSum", "unionFindMin", "unionFindMax"} {
+		assert.Contains(t, xmlStr, `<name>`+name+`</name>`)
+	}
+	assert.Contains(t, xmlStr, `--mode=aggregate --aggregate=max`)
+	assert.Contains(t, xmlStr, `<name>stableClusterIds</name>`)
+	assert.Contains(t, xmlStr, `--mode=clusterid`)
 	assert.Contains(t, xmlStr, `--mode=unionfind`)
 	assert.Contains(t, xmlStr, `--mode=bipartite`)
 	assert.Contains(t, xmlStr, `<format>JSONEachRow</format>`)
@@ -81,6 +81,155 @@ func TestClickHouseUDFs(t *testing.T) {
 				assert.NotEqual(t, vRoots["entity1"], vRoots["entity3"])
 			},
 		},
+		{
+			name:  "unionFindCapped",
+			query: "SELECT unionFindCapped([('user1', 'ip1'), ('user2', 'ip1'), ('user3', 'ip1')], 3) as result FORMAT JSONEachRow",
+			validate: func(t *testing.T, output []byte) {
+				var result unionFindOutput
+				err := json.Unmarshal(output, &result)
+				require.NoError(t, err)
+				require.Len(t, result.Result, 4)
+
+				roots := make(map[string]string)
+				for _, r := range result.Result {
+					roots[r.Value] = r.Root
+				}
+
+				assert.Equal(t, roots["user1"], roots["user2"])
+				assert.NotEqual(t, roots["user1"], roots["user3"])
+			},
+		},
+		{
+			name: "explainUnionFind",
+			query: `SELECT explainUnionFind(
+				[('alice', 'ip1'), ('mallory', 'ip2'), ('bob', 'ip1'), ('ip2', 'bob')], 'alice', 'mallory'
+			) as result FORMAT JSONEachRow`,
+			validate: func(t *testing.T, output []byte) {
+				var result struct {
+					Result []struct {
+						A string `json:"a"`
+						B string `json:"b"`
+					} `json:"result"`
+				}
+				err := json.Unmarshal(output, &result)
+				require.NoError(t, err)
+				require.Len(t, result.Result, 4)
+
+				assert.Equal(t, "alice", result.Result[0].A)
+				assert.Equal(t, "mallory", result.Result[3].A)
+			},
+		},
+		{
+			name: "connectivityTimeline",
+			query: `SELECT connectivityTimeline([
+				('add', 'alice', 'ip1'), ('add', 'bob', 'ip1'), ('connected', 'alice', 'bob'),
+				('remove', 'bob', 'ip1'), ('connected', 'alice', 'bob'), ('components', '', '')
+			]) as result FORMAT JSONEachRow`,
+			validate: func(t *testing.T, output []byte) {
+				var result struct {
+					Result []struct {
+						Event      int  `json:"event"`
+						Connected  bool `json:"connected"`
+						Components int  `json:"components"`
+					} `json:"result"`
+				}
+				err := json.Unmarshal(output, &result)
+				require.NoError(t, err)
+				require.Len(t, result.Result, 3)
+
+				assert.True(t, result.Result[0].Connected)
+				assert.False(t, result.Result[1].Connected)
+				assert.Equal(t, 6, result.Result[2].Event)
+				assert.Equal(t, 2, result.Result[2].Components)
+			},
+		},
+		{
+			name: "singleLinkage",
+			query: `SELECT singleLinkage(
+				[('alice', 'alicia', 0.95), ('bob', 'rob', 0.85), ('alicia', 'bob', 0.7)], [0.9, 0.7]
+			) as result FORMAT JSONEachRow`,
+			validate: func(t *testing.T, output []byte) {
+				var result struct {
+					Result []struct {
+						Threshold float64 `json:"threshold"`
+						Value     string  `json:"value"`
+						Cluster   string  `json:"cluster"`
+					} `json:"result"`
+				}
+				err := json.Unmarshal(output, &result)
+				require.NoError(t, err)
+				require.Len(t, result.Result, 8)
+
+				clusters := make(map[float64]map[string]string)
+				for _, r := range result.Result {
+					if clusters[r.Threshold] == nil {
+						clusters[r.Threshold] = make(map[string]string)
+					}
+					clusters[r.Threshold][r.Value] = r.Cluster
+				}
+
+				assert.Equal(t, clusters[0.9]["alice"], clusters[0.9]["alicia"])
+				assert.NotEqual(t, clusters[0.9]["alice"], clusters[0.9]["bob"])
+				assert.Equal(t, clusters[0.7]["alice"], clusters[0.7]["rob"])
+			},
+		},
+		{
+			name:  "unionFindSum",
+			query: "SELECT unionFindSum([('user1', 'ip1', 10), ('user2', 'ip1', 2.5), ('user3', 'ip2', 7)]) as result FORMAT JSONEachRow",
+			validate: func(t *testing.T, output []byte) {
+				var result struct {
+					Result []struct {
+						Value     string  `json:"value"`
+						Root      string  `json:"root"`
+						Aggregate float64 `json:"aggregate"`
+					} `json:"result"`
+				}
+				err := json.Unmarshal(output, &result)
+				require.NoError(t, err)
+				require.Len(t, result.Result, 5)
+
+				totals := make(map[string]float64)
+				for _, r := range result.Result {
+					totals[r.Value] = r.Aggregate
+				}
+
+				assert.InDelta(t, 12.5, totals["user2"], 1e-9)
+				assert.InDelta(t, 7, totals["ip2"], 1e-9)
+			},
+		},
+		{
+			name:  "stableClusterIds",
+			query: "SELECT stableClusterIds([('user1', 'ip1'), ('user2', 'ip1'), ('user9', 'ip9')], [('user1', 'c1'), ('user3', 'c2')]) as result FORMAT JSONEachRow",
+			validate: func(t *testing.T, output []byte) {
+				var result struct {
+					Result struct {
+						IDs []struct {
+							Value     string `json:"value"`
+							ClusterID string `json:"cluster_id"`
+						} `json:"ids"`
+						Changes []struct {
+							Kind string   `json:"kind"`
+							From []string `json:"from"`
+							To   []string `json:"to"`
+						} `json:"changes"`
+					} `json:"result"`
+				}
+				err := json.Unmarshal(output, &result)
+				require.NoError(t, err)
+				require.Len(t, result.Result.IDs, 5)
+
+				ids := make(map[string]string)
+				for _, r := range result.Result.IDs {
+					ids[r.Value] = r.ClusterID
+				}
+
+				assert.Equal(t, "c1", ids["user2"])
+				assert.Equal(t, "ip9", ids["user9"])
+				require.Len(t, result.Result.Changes, 2)
+				assert.Equal(t, "birth", result.Result.Changes[0].Kind)
+				assert.Equal(t, []string{"c2"}, result.Result.Changes[1].From)
+			},
+		},
 	}
 
 	for _, tt := range tests {
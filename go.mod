@@ -2,7 +2,10 @@ module github.com/maxjustus/bpuf
 
 go 1.24.4
 
-require github.com/stretchr/testify v1.8.1
+require (
+	github.com/stretchr/testify v1.8.1
+	golang.org/x/sys v0.9.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -0,0 +1,116 @@
+// Package linkage builds single-linkage hierarchical clusterings of similarity graphs.
+// Edges are merged from the most similar down, recording every merge in a dendrogram
+// that can then be cut at any threshold without clustering again.
+package linkage
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/maxjustus/bpuf/mst"
+	"github.com/maxjustus/bpuf/unionfind"
+)
+
+// ErrNaNScore is returned when clustering edges scored NaN, which can't be ordered
+var ErrNaNScore = errors.New("linkage: NaN score")
+
+// Merge is the merge of two clusters into a new one.
+// Clusters are numbered like a SciPy linkage matrix: leaf i is the cluster of value i alone
+// and merge i creates cluster n+i, where n is the number of values.
+type Merge[W mst.Weight] struct {
+	Left  int `json:"left"`
+	Right int `json:"right"`
+	// Score of the edge that merged the clusters
+	Score W `json:"score"`
+	// Number of values in the merged cluster
+	Size int `json:"size"`
+}
+
+// Dendrogram is the merge tree of a single-linkage clustering
+type Dendrogram[T comparable, W mst.Weight] struct {
+	// Values in the order they were first seen in the edges
+	Values []T `json:"values"`
+	// Merges from the highest score down
+	Merges []Merge[W] `json:"merges"`
+}
+
+// Cluster clusters the values of edges by single linkage, treating scores as similarities
+// so the highest scoring edges are merged first. Merges of equal score
+// are made in the order their edges are given. It returns ErrNaNScore if any edge scores NaN.
+func Cluster[T comparable, W mst.Weight](edges []mst.Edge[T, W]) (*Dendrogram[T, W], error) {
+	values := unionfind.NewEnumeratedValues[T](len(edges))
+	for _, edge := range edges {
+		if edge.Weight != edge.Weight {
+			return nil, ErrNaNScore
+		}
+
+		values.FetchIndex(edge.From)
+		values.FetchIndex(edge.To)
+	}
+
+	n := len(values.IndexedElements)
+	d := &Dendrogram[T, W]{Values: values.IndexedElements}
+
+	// Every merge joins two trees of the maximum spanning forest,
+	// so its edges in the order they were chosen are the merges
+	uf := unionfind.NewUnionFind(n)
+	cluster := make([]int, n)
+	for i := range cluster {
+		cluster[i] = i
+	}
+
+	for _, edge := range mst.Maximum(edges).Edges {
+		rootA := uf.Find(values.FetchIndex(edge.From))
+		rootB := uf.Find(values.FetchIndex(edge.To))
+		left, right := min(cluster[rootA], cluster[rootB]), max(cluster[rootA], cluster[rootB])
+
+		root := uf.Union(rootA, rootB)
+		cluster[root] = n + len(d.Merges)
+		d.Merges = append(d.Merges, Merge[W]{
+			Left:  left,
+			Right: right,
+			Score: edge.Weight,
+			Size:  uf.Size(root),
+		})
+	}
+
+	return d, nil
+}
+
+// ClustersAt returns the clusters formed by the merges scoring at least threshold,
+// each in the order its values were first seen, ordered by their first value.
+// Values that no such merge includes are clusters of their own,
+// which is every value for a NaN threshold.
+func (d *Dendrogram[T, W]) ClustersAt(threshold W) [][]T {
+	merges := sort.Search(len(d.Merges), func(i int) bool {
+		return !(d.Merges[i].Score >= threshold)
+	})
+
+	n := len(d.Values)
+	uf := unionfind.NewUnionFind(n)
+	uf.SetRootPolicy(unionfind.MinIndexRoot)
+
+	// Any value of a cluster stands in for it
+	leaf := make([]int, n+merges)
+	for i := range n {
+		leaf[i] = i
+		uf.Find(i)
+	}
+	for i, merge := range d.Merges[:merges] {
+		leaf[n+i] = leaf[merge.Left]
+		uf.Union(leaf[merge.Left], leaf[merge.Right])
+	}
+
+	var clusters [][]T
+	position := make(map[int]int)
+	for i, value := range d.Values {
+		root := uf.Find(i)
+		if _, ok := position[root]; !ok {
+			position[root] = len(clusters)
+			clusters = append(clusters, nil)
+		}
+		clusters[position[root]] = append(clusters[position[root]], value)
+	}
+
+	return clusters
+}
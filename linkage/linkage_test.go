@@ -0,0 +1,78 @@
+package linkage_test
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/maxjustus/bpuf/linkage"
+	"github.com/maxjustus/bpuf/mst"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+
+	edges := []mst.Edge[string, float64]{
+		{From: "alice", To: "alicia", Weight: 0.95},
+		{From: "bob", To: "rob", Weight: 0.85},
+		{From: "alicia", To: "alyssa", Weight: 0.75},
+		{From: "alice", To: "alyssa", Weight: 0.7},
+		{From: "rob", To: "alyssa", Weight: 0.6},
+		{From: "carol", To: "carol", Weight: 1},
+	}
+
+	t.Run("records merges from the highest score down", func(t *testing.T) {
+		d, err := linkage.Cluster(edges)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice", "alicia", "bob", "rob", "alyssa", "carol"}, d.Values)
+		assert.Equal(t, []linkage.Merge[float64]{
+			{Left: 0, Right: 1, Score: 0.95, Size: 2},
+			{Left: 2, Right: 3, Score: 0.85, Size: 2},
+			{Left: 4, Right: 6, Score: 0.75, Size: 3},
+			{Left: 7, Right: 8, Score: 0.6, Size: 5},
+		}, d.Merges)
+	})
+
+	t.Run("ClustersAt", func(t *testing.T) {
+		d, err := linkage.Cluster(edges)
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"alice", "alicia"}, {"bob"}, {"rob"}, {"alyssa"}, {"carol"},
+		}, d.ClustersAt(0.9))
+		assert.Equal(t, [][]string{
+			{"alice", "alicia"}, {"bob", "rob"}, {"alyssa"}, {"carol"},
+		}, d.ClustersAt(0.85), "merges at the threshold are included")
+		assert.Equal(t, [][]string{
+			{"alice", "alicia", "alyssa"}, {"bob", "rob"}, {"carol"},
+		}, d.ClustersAt(0.7))
+		assert.Equal(t, [][]string{
+			{"alice", "alicia", "bob", "rob", "alyssa"}, {"carol"},
+		}, d.ClustersAt(0))
+		assert.Len(t, d.ClustersAt(2), len(d.Values))
+		assert.Len(t, d.ClustersAt(math.NaN()), len(d.Values))
+	})
+
+	t.Run("rejects NaN scores", func(t *testing.T) {
+		_, err := linkage.Cluster(append(slices.Clone(edges), mst.Edge[string, float64]{From: "a", To: "b", Weight: math.NaN()}))
+		require.ErrorIs(t, err, linkage.ErrNaNScore)
+	})
+
+	t.Run("exports as JSON", func(t *testing.T) {
+		d, err := linkage.Cluster([]mst.Edge[string, int]{{From: "a", To: "b", Weight: 3}})
+		require.NoError(t, err)
+		data, err := json.Marshal(d)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"values":["a","b"],"merges":[{"left":0,"right":1,"score":3,"size":2}]}`, string(data))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		d, err := linkage.Cluster[string, float64](nil)
+		require.NoError(t, err)
+		assert.Empty(t, d.Merges)
+		assert.Empty(t, d.ClustersAt(0.5))
+	})
+}
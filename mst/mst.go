@@ -0,0 +1,102 @@
+// Package mst computes minimum and maximum spanning forests of weighted graphs
+// with Kruskal's algorithm on top of unionfind.UnionFind.
+package mst
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/maxjustus/bpuf/unionfind"
+)
+
+// Weight is the weight of an edge
+type Weight interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Edge is an undirected edge between values From and To
+type Edge[T comparable, W Weight] struct {
+	From, To T
+	Weight   W
+}
+
+// Forest is a spanning forest with one tree per connected component of a graph
+type Forest[T comparable, W Weight] struct {
+	// Edges of the forest in the order they were chosen
+	Edges []Edge[T, W]
+	// Root value of the component each value is in.
+	// The root is the value of the component seen first in the input edges.
+	Components map[T]T
+	// Total weight of the forest edges in each component by root value
+	Weights map[T]W
+}
+
+// Minimum returns the minimum spanning forest of the graph made of edges.
+// Edges of equal weight are preferred in the order they are given.
+func Minimum[T comparable, W Weight](edges []Edge[T, W]) *Forest[T, W] {
+	return spanningForest(edges, func(a, b Edge[T, W]) int {
+		return cmp.Compare(a.Weight, b.Weight)
+	})
+}
+
+// Maximum returns the maximum spanning forest of the graph made of edges.
+// Edges of equal weight are preferred in the order they are given.
+func Maximum[T comparable, W Weight](edges []Edge[T, W]) *Forest[T, W] {
+	return spanningForest(edges, func(a, b Edge[T, W]) int {
+		return cmp.Compare(b.Weight, a.Weight)
+	})
+}
+
+// spanningForest runs Kruskal's algorithm, taking edges in the order given by compare
+func spanningForest[T comparable, W Weight](edges []Edge[T, W], compare func(a, b Edge[T, W]) int) *Forest[T, W] {
+	values := unionfind.NewEnumeratedValues[T](len(edges))
+	uf := unionfind.NewUnionFind(len(edges))
+	uf.SetRootPolicy(unionfind.MinIndexRoot)
+
+	// Enumerate values in input order so roots don't depend on the weights
+	for _, edge := range edges {
+		uf.Find(values.FetchIndex(edge.From))
+		uf.Find(values.FetchIndex(edge.To))
+	}
+
+	sorted := slices.Clone(edges)
+	slices.SortStableFunc(sorted, compare)
+
+	forest := &Forest[T, W]{}
+	for _, edge := range sorted {
+		from, to := values.FetchIndex(edge.From), values.FetchIndex(edge.To)
+		if uf.Connected(from, to) {
+			continue
+		}
+
+		uf.Union(from, to)
+		forest.Edges = append(forest.Edges, edge)
+	}
+
+	forest.Components = make(map[T]T, len(values.IndexedElements))
+	forest.Weights = make(map[T]W)
+	for i, value := range values.IndexedElements {
+		root := values.At(uf.Find(i))
+		forest.Components[value] = root
+		if _, ok := forest.Weights[root]; !ok {
+			forest.Weights[root] = 0
+		}
+	}
+	for _, edge := range forest.Edges {
+		forest.Weights[forest.Components[edge.From]] += edge.Weight
+	}
+
+	return forest
+}
+
+// TotalWeight returns the total weight of every edge in the forest
+func (f *Forest[T, W]) TotalWeight() W {
+	var total W
+	for _, edge := range f.Edges {
+		total += edge.Weight
+	}
+
+	return total
+}
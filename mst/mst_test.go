@@ -0,0 +1,128 @@
+package mst_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/maxjustus/bpuf/mst"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpanningForest(t *testing.T) {
+	t.Parallel()
+
+	edges := []mst.Edge[string, float64]{
+		{From: "a", To: "b", Weight: 0.9},
+		{From: "b", To: "c", Weight: 0.4},
+		{From: "a", To: "c", Weight: 0.7},
+		{From: "c", To: "d", Weight: 0.2},
+		{From: "x", To: "y", Weight: 0.5},
+		{From: "y", To: "y", Weight: 0.1},
+	}
+
+	t.Run("Minimum", func(t *testing.T) {
+		forest := mst.Minimum(edges)
+		assert.Equal(t, []mst.Edge[string, float64]{
+			{From: "c", To: "d", Weight: 0.2},
+			{From: "b", To: "c", Weight: 0.4},
+			{From: "x", To: "y", Weight: 0.5},
+			{From: "a", To: "c", Weight: 0.7},
+		}, forest.Edges)
+
+		assert.Equal(t, map[string]string{
+			"a": "a", "b": "a", "c": "a", "d": "a",
+			"x": "x", "y": "x",
+		}, forest.Components)
+		assert.InDelta(t, 1.3, forest.Weights["a"], 1e-9)
+		assert.InDelta(t, 0.5, forest.Weights["x"], 1e-9)
+		assert.InDelta(t, 1.8, forest.TotalWeight(), 1e-9)
+	})
+
+	t.Run("Maximum", func(t *testing.T) {
+		forest := mst.Maximum(edges)
+		assert.Equal(t, []mst.Edge[string, float64]{
+			{From: "a", To: "b", Weight: 0.9},
+			{From: "a", To: "c", Weight: 0.7},
+			{From: "x", To: "y", Weight: 0.5},
+			{From: "c", To: "d", Weight: 0.2},
+		}, forest.Edges)
+		assert.InDelta(t, 1.8, forest.Weights["a"], 1e-9)
+	})
+
+	t.Run("ties are broken by input order", func(t *testing.T) {
+		forest := mst.Minimum([]mst.Edge[int, int]{
+			{From: 0, To: 1, Weight: 1},
+			{From: 1, To: 2, Weight: 1},
+			{From: 0, To: 2, Weight: 1},
+		})
+		assert.Equal(t, []mst.Edge[int, int]{
+			{From: 0, To: 1, Weight: 1},
+			{From: 1, To: 2, Weight: 1},
+		}, forest.Edges)
+	})
+
+	t.Run("values without edges to others are components of their own", func(t *testing.T) {
+		forest := mst.Minimum([]mst.Edge[int, int]{{From: 7, To: 7, Weight: 3}})
+		assert.Empty(t, forest.Edges)
+		assert.Equal(t, map[int]int{7: 7}, forest.Components)
+		assert.Equal(t, map[int]int{7: 0}, forest.Weights)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		forest := mst.Minimum[string, int](nil)
+		assert.Empty(t, forest.Edges)
+		assert.Empty(t, forest.Components)
+		assert.Zero(t, forest.TotalWeight())
+	})
+
+	t.Run("is minimal", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 8
+
+		var complete []mst.Edge[int, int]
+		for a := range n {
+			for b := a + 1; b < n; b++ {
+				complete = append(complete, mst.Edge[int, int]{From: a, To: b, Weight: rng.Intn(100)})
+			}
+		}
+
+		forest := mst.Minimum(complete)
+		require.Len(t, forest.Edges, n-1)
+
+		// Every edge outside the tree must weigh at least as much as
+		// every tree edge on the path between its ends (the cycle property)
+		adjacent := make(map[int][]mst.Edge[int, int])
+		for _, edge := range forest.Edges {
+			adjacent[edge.From] = append(adjacent[edge.From], edge)
+			adjacent[edge.To] = append(adjacent[edge.To], edge)
+		}
+
+		var heaviest func(at, to, from, limit int) (int, bool)
+		heaviest = func(at, to, from, limit int) (int, bool) {
+			if at == to {
+				return limit, true
+			}
+			for _, edge := range adjacent[at] {
+				next := edge.To
+				if next == at {
+					next = edge.From
+				}
+				if next == from {
+					continue
+				}
+				if w, ok := heaviest(next, to, at, max(limit, edge.Weight)); ok {
+					return w, true
+				}
+			}
+			return 0, false
+		}
+
+		for _, edge := range complete {
+			w, ok := heaviest(edge.From, edge.To, -1, 0)
+			require.True(t, ok)
+			assert.GreaterOrEqual(t, edge.Weight, w)
+		}
+	})
+}
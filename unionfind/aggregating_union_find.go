@@ -0,0 +1,374 @@
+package unionfind
+
+import "iter"
+
+// AggregatingUnionFind is a union-find structure that keeps an aggregate
+// of the values added to the elements of each set, such as total spend or earliest signup.
+// The aggregate is held at the root and combined with the merge function
+// when sets are merged, so it's always available without another pass over the members.
+// The underlying UnionFind isn't exposed, since merging, removals, rollback and encoding
+// on it would leave the aggregates behind, so only the queries and settings
+// that keep the aggregates right are available.
+type AggregatingUnionFind[A any] struct {
+	sets *UnionFind
+	// Aggregate of the values added to each set by tree root index,
+	// missing for sets no value has been added to
+	aggregates map[int]A
+	merge      func(a, b A) A
+	// Number of unions that merged two sets, so RootCount can subtract them
+	// from the number of elements added
+	merges int
+}
+
+// NewAggregatingUnionFind creates a new AggregatingUnionFind with the specified capacity
+// combining values with merge, which should be associative and commutative
+// since values are combined in the order sets happen to be merged in
+func NewAggregatingUnionFind[A any](capacity int, merge func(a, b A) A) *AggregatingUnionFind[A] {
+	return newAggregatingUnionFindFrom(NewUnionFind(capacity), merge)
+}
+
+func newAggregatingUnionFindFrom[A any](uf *UnionFind, merge func(a, b A) A) *AggregatingUnionFind[A] {
+	return &AggregatingUnionFind[A]{
+		sets:       uf,
+		aggregates: make(map[int]A),
+		merge:      merge,
+	}
+}
+
+// Add adds value to the aggregate of the set containing the element at index
+func (uf *AggregatingUnionFind[A]) Add(index int, value A) {
+	root := uf.sets.findRoot(index)
+	if aggregate, ok := uf.aggregates[root]; ok {
+		value = uf.merge(aggregate, value)
+	}
+	uf.aggregates[root] = value
+}
+
+// Aggregate returns the aggregate of the values added to the set containing the element at index,
+// and false if none have been. Elements that have not been added are not added by the call.
+func (uf *AggregatingUnionFind[A]) Aggregate(index int) (A, bool) {
+	if !uf.sets.Contains(index) {
+		var zero A
+		return zero, false
+	}
+
+	aggregate, ok := uf.aggregates[uf.sets.findRoot(index)]
+	return aggregate, ok
+}
+
+// Union merges the sets containing a and b along with their aggregates,
+// returning the root of the merged set. It returns the root of a's set and false
+// without merging anything if the merged set would be larger than the maximum set size.
+func (uf *AggregatingUnionFind[A]) Union(a, b int) (int, bool) {
+	rootA := uf.sets.findRoot(a)
+	rootB := uf.sets.findRoot(b)
+
+	root, ok := uf.sets.union(a, b)
+	if !ok {
+		uf.sets.reject(a, b)
+		return root, false
+	}
+	if rootA != rootB {
+		uf.merges++
+		if uf.sets.provenance != nil {
+			uf.sets.provenance.record([2]int{a, b}, a, b)
+		}
+		uf.mergeAggregates(rootA, rootB, uf.sets.findRoot(a))
+	}
+
+	return root, true
+}
+
+// mergeAggregates moves the aggregates of the sets formerly rooted at rootA and rootB
+// onto the merged set rooted at root
+func (uf *AggregatingUnionFind[A]) mergeAggregates(rootA, rootB, root int) {
+	aggregateA, okA := uf.aggregates[rootA]
+	aggregateB, okB := uf.aggregates[rootB]
+	delete(uf.aggregates, rootA)
+	delete(uf.aggregates, rootB)
+
+	switch {
+	case okA && okB:
+		uf.aggregates[root] = uf.merge(aggregateA, aggregateB)
+	case okA:
+		uf.aggregates[root] = aggregateA
+	case okB:
+		uf.aggregates[root] = aggregateB
+	}
+}
+
+// UnionAll unions both ends of every edge in order.
+// Workers is ignored, since aggregates are merged one union at a time.
+func (uf *AggregatingUnionFind[A]) UnionAll(edges [][2]int, workers int) {
+	for _, edge := range edges {
+		uf.Union(edge[0], edge[1])
+	}
+}
+
+// Find returns the root of the set containing the given index
+func (uf *AggregatingUnionFind[A]) Find(index int) int {
+	return uf.sets.Find(index)
+}
+
+// Contains reports whether the element at the given index has been added
+func (uf *AggregatingUnionFind[A]) Contains(index int) bool {
+	return uf.sets.Contains(index)
+}
+
+// TryFind returns the root of the set containing the given index
+// and false if the element has not been added. The structure is left unchanged.
+func (uf *AggregatingUnionFind[A]) TryFind(index int) (int, bool) {
+	return uf.sets.TryFind(index)
+}
+
+// Connected reports whether a and b are in the same set.
+// Elements that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *AggregatingUnionFind[A]) Connected(a, b int) bool {
+	return uf.sets.Connected(a, b)
+}
+
+// Size returns the exact number of elements in the set containing the given index,
+// 0 if the element has not been added
+func (uf *AggregatingUnionFind[A]) Size(index int) int {
+	return uf.sets.Size(index)
+}
+
+// Members returns the indices of all elements in the set containing index,
+// nil if the element has not been added
+func (uf *AggregatingUnionFind[A]) Members(index int) []int {
+	return uf.sets.Members(index)
+}
+
+// Roots returns an iterator over the root index of every set
+func (uf *AggregatingUnionFind[A]) Roots() iter.Seq[int] {
+	return uf.sets.Roots()
+}
+
+// Sets returns an iterator over every set, yielding its root index
+// along with the indices of all of its members
+func (uf *AggregatingUnionFind[A]) Sets() iter.Seq2[int, []int] {
+	return uf.sets.Sets()
+}
+
+// LargestSets returns the root indices of the k largest sets, largest first
+func (uf *AggregatingUnionFind[A]) LargestSets(k int) []int {
+	return uf.sets.LargestSets(k)
+}
+
+// RootCount returns the number of sets
+func (uf *AggregatingUnionFind[A]) RootCount() int {
+	return uf.sets.RootCount - uf.merges
+}
+
+// NonSingletonCount returns the number of sets with more than one member
+func (uf *AggregatingUnionFind[A]) NonSingletonCount() int {
+	return uf.sets.NonSingletonCount
+}
+
+// SetMaxSetSize limits the size of the sets unions may create, see UnionFind.SetMaxSetSize
+func (uf *AggregatingUnionFind[A]) SetMaxSetSize(size int) {
+	uf.sets.SetMaxSetSize(size)
+}
+
+// MaxSetSize returns the largest set a union may create, 0 if there's no limit
+func (uf *AggregatingUnionFind[A]) MaxSetSize() int {
+	return uf.sets.MaxSetSize()
+}
+
+// RejectedEdges returns the edges whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (uf *AggregatingUnionFind[A]) RejectedEdges() [][2]int {
+	return uf.sets.RejectedEdges()
+}
+
+// SetRootPolicy sets which member of each set is reported as its root
+func (uf *AggregatingUnionFind[A]) SetRootPolicy(policy RootPolicy) {
+	uf.sets.SetRootPolicy(policy)
+}
+
+// EnableProvenance starts recording the unions that merge sets, see UnionFind.EnableProvenance
+func (uf *AggregatingUnionFind[A]) EnableProvenance() {
+	uf.sets.EnableProvenance()
+}
+
+// DisableProvenance stops recording unions and forgets the ones recorded
+func (uf *AggregatingUnionFind[A]) DisableProvenance() {
+	uf.sets.DisableProvenance()
+}
+
+// Explain returns the chain of edges passed to Union that connects a to b,
+// and false if they aren't connected or provenance wasn't enabled when they were
+func (uf *AggregatingUnionFind[A]) Explain(a, b int) ([][2]int, bool) {
+	return uf.sets.Explain(a, b)
+}
+
+// AggregatingUnionFindWithValues represents an aggregating union-find structure with generic values.
+// Like AggregatingUnionFind it only exposes the operations that keep the aggregates right.
+type AggregatingUnionFindWithValues[T comparable, A any] struct {
+	sets       *AlgoUnionFindWithValues[T]
+	aggregates *AggregatingUnionFind[A]
+}
+
+// NewAggregatingUnionFindWithValues creates a new AggregatingUnionFindWithValues with the specified capacity
+// combining values with merge, which should be associative and commutative
+func NewAggregatingUnionFindWithValues[T comparable, A any](capacity int, merge func(a, b A) A) *AggregatingUnionFindWithValues[T, A] {
+	uf := NewUnionFindWithValues[T](capacity)
+	return &AggregatingUnionFindWithValues[T, A]{
+		sets:       uf,
+		aggregates: newAggregatingUnionFindFrom(uf.UnionFind, merge),
+	}
+}
+
+// Add adds aggregate value to the aggregate of the set containing value
+func (uf *AggregatingUnionFindWithValues[T, A]) Add(value T, aggregate A) {
+	uf.aggregates.Add(uf.sets.values.FetchIndex(value), aggregate)
+}
+
+// Aggregate returns the aggregate of the set containing value, and false if nothing
+// has been added to it. Values that have not been added are not added by the call.
+func (uf *AggregatingUnionFindWithValues[T, A]) Aggregate(value T) (A, bool) {
+	index, ok := uf.sets.values.Lookup(value)
+	if !ok {
+		var zero A
+		return zero, false
+	}
+
+	return uf.aggregates.Aggregate(index)
+}
+
+// Union merges the sets containing values a and b along with their aggregates,
+// returning the root index of the merged set. It returns the root index of a's set
+// and false without merging anything if the merged set would be larger than the maximum set size.
+func (uf *AggregatingUnionFindWithValues[T, A]) Union(a, b T) (int, bool) {
+	return uf.aggregates.Union(uf.sets.values.FetchIndex(a), uf.sets.values.FetchIndex(b))
+}
+
+// UnionReturningValue is like Union but returns the root value
+func (uf *AggregatingUnionFindWithValues[T, A]) UnionReturningValue(a, b T) (T, bool) {
+	root, ok := uf.Union(a, b)
+	return uf.sets.values.At(root), ok
+}
+
+// UnionAll unions every pair of values in order.
+// Workers is ignored, since aggregates are merged one union at a time.
+func (uf *AggregatingUnionFindWithValues[T, A]) UnionAll(pairs [][2]T, workers int) {
+	for _, pair := range pairs {
+		uf.Union(pair[0], pair[1])
+	}
+}
+
+// Find returns the root index of the set containing the given value
+func (uf *AggregatingUnionFindWithValues[T, A]) Find(value T) int {
+	return uf.sets.Find(value)
+}
+
+// FindReturningValue returns the root value of the set containing the given value
+func (uf *AggregatingUnionFindWithValues[T, A]) FindReturningValue(value T) T {
+	return uf.sets.FindReturningValue(value)
+}
+
+// Contains reports whether the given value has been added
+func (uf *AggregatingUnionFindWithValues[T, A]) Contains(value T) bool {
+	return uf.sets.Contains(value)
+}
+
+// TryFind returns the root index of the set containing the given value
+// and false if the value has not been added. The structure is left unchanged.
+func (uf *AggregatingUnionFindWithValues[T, A]) TryFind(value T) (int, bool) {
+	return uf.sets.TryFind(value)
+}
+
+// TryFindReturningValue returns the root value of the set containing the given value
+// and false if the value has not been added. The structure is left unchanged.
+func (uf *AggregatingUnionFindWithValues[T, A]) TryFindReturningValue(value T) (T, bool) {
+	return uf.sets.TryFindReturningValue(value)
+}
+
+// Connected reports whether values a and b are in the same set.
+// Values that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *AggregatingUnionFindWithValues[T, A]) Connected(a, b T) bool {
+	return uf.sets.Connected(a, b)
+}
+
+// SizeOf returns the exact number of values in the set containing the given value,
+// 0 if the value has not been added
+func (uf *AggregatingUnionFindWithValues[T, A]) SizeOf(value T) int {
+	return uf.sets.SizeOf(value)
+}
+
+// Members returns all values in the set containing the given value,
+// nil if the value has not been added
+func (uf *AggregatingUnionFindWithValues[T, A]) Members(value T) []T {
+	return uf.sets.Members(value)
+}
+
+// Roots returns an iterator over the root value of every set
+func (uf *AggregatingUnionFindWithValues[T, A]) Roots() iter.Seq[T] {
+	return uf.sets.Roots()
+}
+
+// Sets returns an iterator over every set, yielding its root value
+// along with the values of all of its members
+func (uf *AggregatingUnionFindWithValues[T, A]) Sets() iter.Seq2[T, []T] {
+	return uf.sets.Sets()
+}
+
+// LargestSets returns the root values of the k largest sets, largest first
+func (uf *AggregatingUnionFindWithValues[T, A]) LargestSets(k int) []T {
+	return uf.sets.LargestSets(k)
+}
+
+// RootCount returns the number of sets
+func (uf *AggregatingUnionFindWithValues[T, A]) RootCount() int {
+	return uf.aggregates.RootCount()
+}
+
+// NonSingletonCount returns the number of sets with more than one member
+func (uf *AggregatingUnionFindWithValues[T, A]) NonSingletonCount() int {
+	return uf.sets.NonSingletonCount
+}
+
+// SetMaxSetSize limits the size of the sets unions may create, see UnionFind.SetMaxSetSize
+func (uf *AggregatingUnionFindWithValues[T, A]) SetMaxSetSize(size int) {
+	uf.sets.SetMaxSetSize(size)
+}
+
+// MaxSetSize returns the largest set a union may create, 0 if there's no limit
+func (uf *AggregatingUnionFindWithValues[T, A]) MaxSetSize() int {
+	return uf.sets.MaxSetSize()
+}
+
+// RejectedEdges returns the pairs of values whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (uf *AggregatingUnionFindWithValues[T, A]) RejectedEdges() [][2]T {
+	return uf.sets.RejectedEdges()
+}
+
+// SetRootPolicy sets which member of each set is reported as its root
+func (uf *AggregatingUnionFindWithValues[T, A]) SetRootPolicy(policy RootPolicy) {
+	uf.sets.SetRootPolicy(policy)
+}
+
+// SetRootCmp reports the smallest member of each set by cmp as its root,
+// see AlgoUnionFindWithValues.SetRootCmp
+func (uf *AggregatingUnionFindWithValues[T, A]) SetRootCmp(cmp func(a, b T) int) {
+	uf.sets.SetRootCmp(cmp)
+}
+
+// EnableProvenance starts recording the unions that merge sets, see UnionFind.EnableProvenance
+func (uf *AggregatingUnionFindWithValues[T, A]) EnableProvenance() {
+	uf.sets.EnableProvenance()
+}
+
+// DisableProvenance stops recording unions and forgets the ones recorded
+func (uf *AggregatingUnionFindWithValues[T, A]) DisableProvenance() {
+	uf.sets.DisableProvenance()
+}
+
+// Explain returns the chain of pairs passed to Union that connects values a and b,
+// and false if they aren't connected or provenance wasn't enabled when they were
+func (uf *AggregatingUnionFindWithValues[T, A]) Explain(a, b T) ([][2]T, bool) {
+	return uf.sets.Explain(a, b)
+}
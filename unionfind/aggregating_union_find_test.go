@@ -0,0 +1,155 @@
+package unionfind_test
+
+import (
+	"encoding"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregatingUnionFind(t *testing.T) {
+	t.Parallel()
+
+	sum := func(a, b int) int { return a + b }
+
+	t.Run("keeps the aggregate of each set", func(t *testing.T) {
+		uf := unionfind.NewAggregatingUnionFind(0, sum)
+		uf.Add(0, 10)
+		uf.Add(1, 20)
+		uf.Add(3, 5)
+		uf.Union(0, 1)
+		uf.Union(2, 1)
+
+		total, ok := uf.Aggregate(2)
+		require.True(t, ok)
+		assert.Equal(t, 30, total)
+
+		uf.Add(2, 1)
+		total, _ = uf.Aggregate(0)
+		assert.Equal(t, 31, total, "values added after merging count too")
+
+		total, _ = uf.Aggregate(3)
+		assert.Equal(t, 5, total)
+
+		uf.Union(4, 5)
+		_, ok = uf.Aggregate(4)
+		assert.False(t, ok, "nothing was added to the set")
+		_, ok = uf.Aggregate(6)
+		assert.False(t, ok)
+		assert.False(t, uf.Contains(6))
+		assert.Equal(t, 3, uf.RootCount())
+	})
+
+	t.Run("matches aggregating the members", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 300
+
+		uf := unionfind.NewAggregatingUnionFind(0, sum)
+		values := make(map[int]int)
+		for i := range n {
+			if rng.Intn(2) == 0 {
+				values[i] = rng.Intn(100)
+				uf.Add(i, values[i])
+			}
+		}
+
+		edges := make([][2]int, n)
+		for i := range edges {
+			edges[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+		}
+		uf.UnionAll(edges, 4)
+
+		for i := range n {
+			if !uf.Contains(i) {
+				continue
+			}
+
+			total, added := 0, false
+			for j, value := range values {
+				if uf.Connected(i, j) {
+					total += value
+					added = true
+				}
+			}
+
+			aggregate, ok := uf.Aggregate(i)
+			require.Equal(t, added, ok)
+			assert.Equal(t, total, aggregate)
+		}
+	})
+
+	t.Run("rejected unions keep their aggregates apart", func(t *testing.T) {
+		uf := unionfind.NewAggregatingUnionFind(0, sum)
+		uf.SetMaxSetSize(2)
+		uf.Add(0, 1)
+		uf.Add(2, 2)
+		uf.Union(0, 1)
+		_, ok := uf.Union(1, 2)
+		assert.False(t, ok)
+
+		total, _ := uf.Aggregate(1)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, [][2]int{{1, 2}}, uf.RejectedEdges())
+	})
+
+	t.Run("values", func(t *testing.T) {
+		earliest := func(a, b time.Time) time.Time {
+			if b.Before(a) {
+				return b
+			}
+			return a
+		}
+
+		uf := unionfind.NewAggregatingUnionFindWithValues[string](0, earliest)
+		uf.Add("alice", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+		uf.Add("alicia", time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC))
+		uf.Add("bob", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+		uf.UnionAll([][2]string{{"alice", "alice@example.com"}, {"alicia", "alice@example.com"}}, 4)
+
+		signup, ok := uf.Aggregate("alice@example.com")
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), signup)
+
+		root, ok := uf.UnionReturningValue("alice", "bob")
+		require.True(t, ok)
+		assert.Equal(t, "alice", root)
+		signup, _ = uf.Aggregate("alicia")
+		assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), signup)
+
+		_, ok = uf.Aggregate("carol")
+		assert.False(t, ok)
+		assert.False(t, uf.Contains("carol"))
+	})
+	t.Run("operations that would leave the aggregates behind aren't exposed", func(t *testing.T) {
+		var uf any = unionfind.NewAggregatingUnionFind(0, sum)
+		_, ok := uf.(interface{ Merge(*unionfind.UnionFind) })
+		assert.False(t, ok)
+		_, ok = uf.(encoding.BinaryMarshaler)
+		assert.False(t, ok)
+		_, ok = uf.(encoding.BinaryUnmarshaler)
+		assert.False(t, ok)
+		_, ok = uf.(interface{ RemoveEdge(a, b int) bool })
+		assert.False(t, ok)
+		_, ok = uf.(interface {
+			Rollback(unionfind.Checkpoint) error
+		})
+		assert.False(t, ok)
+
+		var values any = unionfind.NewAggregatingUnionFindWithValues[string](0, sum)
+		_, ok = values.(interface {
+			Merge(*unionfind.AlgoUnionFindWithValues[string])
+		})
+		assert.False(t, ok)
+		_, ok = values.(encoding.BinaryMarshaler)
+		assert.False(t, ok)
+		_, ok = values.(encoding.BinaryUnmarshaler)
+		assert.False(t, ok)
+		_, ok = values.(interface{ Remove(string) bool })
+		assert.False(t, ok)
+	})
+}
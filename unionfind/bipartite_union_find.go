@@ -2,17 +2,29 @@
 // and union by rank optimization for efficient set operations.
 package unionfind
 
+import "slices"
+
 // BipartiteUnionFind facilitates iterative construction of a graph via union find
 // given only transitive edges between sets U and V in a bipartite graph.
 // Works by caching the last found root for each U in V and then using the cached V for a given U
 // as the root for the next union operation on the same U.
 // Union(U1, V1), Union(U1, V2) results in Union(V1, V1) and Union(V1, V2) in underlying UnionFind.
 
-// BipartiteUnionFind represents a union-find structure for bipartite graphs
+// BipartiteUnionFind represents a union-find structure for bipartite graphs.
+// Rollback and removals are not supported.
 type BipartiteUnionFind struct {
 	*UnionFind
 	lastRootForUInV            []int
 	lastRootForUInVInitialized []bool
+
+	// Relations deferred until Flush while maxVDegree is set, as (u, v)
+	deferred [][2]int
+	// Number of distinct Us related to each V by index, only counted once maxVDegree is set
+	vDegree []int
+	// Relations counted towards vDegree as (u, v), so repeats aren't counted again
+	counted map[[2]int]struct{}
+	// Most relations a V may have and still bridge them, 0 for no limit, see SetMaxVDegree
+	maxVDegree int
 }
 
 // NewBipartiteUnionFind creates a new BipartiteUnionFind with the specified capacity
@@ -24,11 +36,29 @@ func NewBipartiteUnionFind(capacity int) *BipartiteUnionFind {
 	}
 }
 
-// Union connects elements u and v, returning the root of the merged set
+// Union connects elements u and v, returning the root of the merged set.
+// If the merged set would be larger than the maximum set size the relation is
+// recorded in RejectedEdges instead, u stays associated with the set it was,
+// and the root of that set is returned.
+// While there's a maximum V degree the relation is deferred until Flush instead
+// and the root of v's set is returned, see SetMaxVDegree.
 func (buf *BipartiteUnionFind) Union(u, v int) int {
-	if len(buf.lastRootForUInV) <= u {
-		buf.lastRootForUInV = expandSlice(buf.lastRootForUInV, u)
-		buf.lastRootForUInVInitialized = expandSlice(buf.lastRootForUInVInitialized, u)
+	if buf.maxVDegree > 0 {
+		buf.countDegree(u, v)
+		buf.deferred = append(buf.deferred, [2]int{u, v})
+		return buf.Find(v)
+	}
+
+	return buf.relate(u, v)
+}
+
+// relate connects elements u and v without counting the relation towards v's degree.
+// Relations to a suppressed V only add v, leaving u associated with the set it was.
+func (buf *BipartiteUnionFind) relate(u, v int) int {
+	buf.growU(u)
+
+	if buf.Suppressed(v) {
+		return buf.Find(v)
 	}
 
 	var uIdxForV int
@@ -38,17 +68,152 @@ func (buf *BipartiteUnionFind) Union(u, v int) int {
 		uIdxForV = buf.lastRootForUInV[u]
 	}
 
-	newRoot := buf.UnionFind.Union(uIdxForV, v)
-	buf.lastRootForUInV[u] = newRoot
-	buf.lastRootForUInVInitialized[u] = true
+	// u joining the forest for the first time links it to v just like a merge does
+	separate := buf.provenance != nil && (!buf.lastRootForUInVInitialized[u] || buf.findRoot(uIdxForV) != buf.findRoot(v))
+
+	newRoot, ok := buf.union(uIdxForV, v)
+	if !ok {
+		buf.reject(u, v)
+		return newRoot
+	}
+
+	if separate {
+		buf.provenance.record([2]int{u, v}, uNode(u), vNode(v))
+	}
+	buf.setAssociation(u, newRoot)
 	return newRoot
 }
 
+func (buf *BipartiteUnionFind) setAssociation(u, v int) {
+	buf.lastRootForUInV[u] = v
+	buf.lastRootForUInVInitialized[u] = true
+}
+
+func (buf *BipartiteUnionFind) growU(u int) {
+	if len(buf.lastRootForUInV) <= u {
+		buf.lastRootForUInV = expandSlice(buf.lastRootForUInV, u)
+		buf.lastRootForUInVInitialized = expandSlice(buf.lastRootForUInVInitialized, u)
+	}
+}
+
+// UnionAll connects the u and v elements of every edge,
+// splitting the resulting unions between workers goroutines as described in UnionFind.UnionAll.
+// When there's a maximum set size or provenance is enabled
+// the edges are unioned one at a time in order.
+// When there's a maximum V degree, the degrees of the whole batch are counted
+// and then the batch is flushed along with any relations deferred before it,
+// so a V over the limit never bridges any of them.
+func (buf *BipartiteUnionFind) UnionAll(edges [][2]int, workers int) {
+	if buf.maxVDegree > 0 {
+		for _, edge := range edges {
+			buf.countDegree(edge[0], edge[1])
+		}
+		edges = slices.Concat(buf.deferred, edges)
+		buf.deferred = nil
+	}
+
+	buf.relateAll(edges, workers)
+}
+
+// relateAll relates the u and v elements of every edge
+// without counting the relations towards the degrees of the Vs
+func (buf *BipartiteUnionFind) relateAll(edges [][2]int, workers int) {
+	if buf.maxSetSize > 0 || buf.provenance != nil {
+		for _, edge := range edges {
+			buf.relate(edge[0], edge[1])
+		}
+		return
+	}
+
+	// Each edge links v with the V last associated with u,
+	// which doesn't need to be a root for the sets to end up the same
+	vEdges := make([][2]int, 0, len(edges))
+	for _, edge := range edges {
+		u, v := edge[0], edge[1]
+		buf.growU(u)
+
+		if buf.Suppressed(v) {
+			buf.addElement(v)
+			continue
+		}
+
+		if !buf.lastRootForUInVInitialized[u] {
+			buf.setAssociation(u, v)
+		}
+
+		vEdges = append(vEdges, [2]int{buf.lastRootForUInV[u], v})
+	}
+
+	buf.UnionFind.UnionAll(vEdges, workers)
+}
+
+// RejectedEdges returns the (u, v) relations whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (buf *BipartiteUnionFind) RejectedEdges() [][2]int {
+	return buf.UnionFind.RejectedEdges()
+}
+
+// AssociatedUs returns the indices of every U associated with the set containing v
+func (buf *BipartiteUnionFind) AssociatedUs(v int) []int {
+	if !buf.Contains(v) {
+		return nil
+	}
+
+	return buf.usByRoot()[buf.Find(v)]
+}
+
+// usByRoot returns the indices of the Us associated with each set by root index
+func (buf *BipartiteUnionFind) usByRoot() map[int][]int {
+	us := make(map[int][]int)
+	for u, initialized := range buf.lastRootForUInVInitialized {
+		if initialized {
+			root := buf.Find(buf.lastRootForUInV[u])
+			us[root] = append(us[root], u)
+		}
+	}
+
+	return us
+}
+
 // FindAssociatedRoot finds the root associated with element u in the V set
 func (buf *BipartiteUnionFind) FindAssociatedRoot(u int) (int, bool) {
-	if len(buf.lastRootForUInV) <= u {
+	if len(buf.lastRootForUInV) <= u || !buf.lastRootForUInVInitialized[u] {
 		return -1, false
 	}
 
 	return buf.Find(buf.lastRootForUInV[u]), true
 }
+
+// TryFindAssociatedRoot finds the root associated with element u in the V set
+// without compressing paths, leaving the structure unchanged
+func (buf *BipartiteUnionFind) TryFindAssociatedRoot(u int) (int, bool) {
+	if u < 0 || len(buf.lastRootForUInV) <= u || !buf.lastRootForUInVInitialized[u] {
+		return -1, false
+	}
+
+	return buf.TryFind(buf.lastRootForUInV[u])
+}
+
+// Checkpoint returns an empty checkpoint without switching to rollback mode,
+// since the U associations and V degrees can't be rolled back
+func (buf *BipartiteUnionFind) Checkpoint() Checkpoint {
+	return Checkpoint{}
+}
+
+// Rollback returns ErrRollbackNotSupported, since the U associations
+// and V degrees can't be rolled back
+func (buf *BipartiteUnionFind) Rollback(Checkpoint) error {
+	return ErrRollbackNotSupported
+}
+
+// RemoveEdge always returns false without removing anything,
+// since the U associations can't be split between the parts of a set
+func (buf *BipartiteUnionFind) RemoveEdge(a, b int) bool {
+	return false
+}
+
+// RemoveElement always returns false without removing anything,
+// since the U associations can't be split between the parts of a set
+func (buf *BipartiteUnionFind) RemoveElement(index int) bool {
+	return false
+}
@@ -38,6 +38,85 @@ func TestBipartiteUnionFind(t *testing.T) {
 			assert.Equal(t, 2, root, "6 should be in the same set as 2 after union of 7 and 2")
 		})
 	})
+
+	t.Run("FindAssociatedRoot", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFind(10)
+		uf.Union(1, 2)
+
+		_, ok := uf.FindAssociatedRoot(3)
+		assert.False(t, ok, "U elements without any union should not be associated")
+		_, ok = uf.TryFindAssociatedRoot(3)
+		assert.False(t, ok)
+
+		root, ok := uf.TryFindAssociatedRoot(1)
+		assert.True(t, ok)
+		assert.Equal(t, 2, root)
+	})
+
+	t.Run("UnionAll", func(t *testing.T) {
+		const n = 5000
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		edges := make([][2]int, n)
+		for i := range edges {
+			edges[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+		}
+
+		expected := unionfind.NewBipartiteUnionFind(0)
+		for _, edge := range edges {
+			expected.Union(edge[0], edge[1])
+		}
+
+		uf := unionfind.NewBipartiteUnionFind(0)
+		uf.UnionAll(edges, 4)
+
+		assertSamePartition(t, n, expected.Find, uf.Find)
+
+		// Us without any relation are given a set of their own
+		associatedRoot := func(buf *unionfind.BipartiteUnionFind) func(int) int {
+			return func(u int) int {
+				root, ok := buf.FindAssociatedRoot(u)
+				if !ok {
+					return -1 - u
+				}
+				return root
+			}
+		}
+		assertSamePartition(t, n, associatedRoot(expected), associatedRoot(uf))
+	})
+
+	t.Run("maximum set size", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFind(0)
+		buf.SetMaxSetSize(2)
+		buf.Union(0, 10)
+		buf.Union(0, 11)
+		buf.Union(1, 12)
+
+		root := buf.Union(0, 12)
+		assert.Equal(t, buf.Find(10), root)
+		assert.False(t, buf.Connected(10, 12))
+
+		// u stays associated with the set it was in
+		vRoot, ok := buf.FindAssociatedRoot(0)
+		assert.True(t, ok)
+		assert.Equal(t, buf.Find(11), vRoot)
+
+		buf.UnionAll([][2]int{{2, 13}, {2, 12}, {2, 14}}, 4)
+		assert.Equal(t, [][2]int{{0, 12}, {2, 14}}, buf.RejectedEdges())
+		assert.True(t, buf.Connected(12, 13))
+	})
+
+	t.Run("AssociatedUs", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFind(0)
+		buf.Union(0, 10)
+		buf.Union(1, 11)
+		buf.Union(1, 10)
+		buf.Union(2, 12)
+
+		assert.Equal(t, []int{0, 1}, buf.AssociatedUs(11))
+		assert.Equal(t, []int{2}, buf.AssociatedUs(12))
+		assert.Nil(t, buf.AssociatedUs(13))
+		assert.False(t, buf.Contains(13))
+	})
 }
 
 func BenchmarkBipartiteUnionFind(b *testing.B) {
@@ -47,4 +126,26 @@ func BenchmarkBipartiteUnionFind(b *testing.B) {
 			uf.Union(i, i+rand.Intn(1000)) //nolint:gosec // test code using weak random is acceptable
 		}
 	})
+
+	const n = 1000000
+	edges := make([][2]int, n)
+	for i := range edges {
+		edges[i] = [2]int{i, i + rand.Intn(1000)} //nolint:gosec // test code using weak random is acceptable
+	}
+
+	b.Run("Union() 1M edges", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			uf := unionfind.NewBipartiteUnionFind(n)
+			for _, edge := range edges {
+				uf.Union(edge[0], edge[1])
+			}
+		}
+	})
+
+	b.Run("UnionAll() 1M edges", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			uf := unionfind.NewBipartiteUnionFind(n)
+			uf.UnionAll(edges, 0)
+		}
+	})
 }
@@ -1,5 +1,7 @@
 package unionfind
 
+import "iter"
+
 // BipartiteUnionFindWithValues provides a bipartite union-find structure with generic values
 type BipartiteUnionFindWithValues[U, V comparable] struct {
 	*BipartiteUnionFind
@@ -7,6 +9,19 @@ type BipartiteUnionFindWithValues[U, V comparable] struct {
 	VValues *EnumeratedValues[V]
 }
 
+// Relation is an edge between a U and a V element of a bipartite graph
+type Relation[U, V comparable] struct {
+	U U
+	V V
+}
+
+// Cluster is a set of V elements along with every U element associated with it
+type Cluster[U, V comparable] struct {
+	Root V
+	Us   []U
+	Vs   []V
+}
+
 // NewBipartiteUnionFindWithValues creates a new BipartiteUnionFindWithValues.
 // A bipartite graph is a graph whose vertices can be divided into two disjoint sets U and V
 // such that every edge connects a vertex in U to one in V.
@@ -36,6 +51,92 @@ func (buf *BipartiteUnionFindWithValues[U, V]) FindVRootForUIndex(uIndex int) (V
 	return buf.VValues.At(vIndex), true
 }
 
+// ContainsU reports whether the given U value has been added
+func (buf *BipartiteUnionFindWithValues[U, V]) ContainsU(u U) bool {
+	return buf.UValues.Contains(u)
+}
+
+// ContainsV reports whether the given V value has been added
+func (buf *BipartiteUnionFindWithValues[U, V]) ContainsV(v V) bool {
+	return buf.VValues.Contains(v)
+}
+
+// TryFindVRootForU finds the V root for the given U element
+// and false if the U element has not been added. The structure is left unchanged.
+func (buf *BipartiteUnionFindWithValues[U, V]) TryFindVRootForU(u U) (V, bool) {
+	var zero V
+
+	uIndex, ok := buf.UValues.Lookup(u)
+	if !ok {
+		return zero, false
+	}
+
+	vIndex, ok := buf.TryFindAssociatedRoot(uIndex)
+	if !ok {
+		return zero, false
+	}
+
+	return buf.VValues.At(vIndex), true
+}
+
+// TryFindReturningValue finds the root of the given V element and returns it as a value,
+// and false if the V element has not been added. The structure is left unchanged.
+func (buf *BipartiteUnionFindWithValues[U, V]) TryFindReturningValue(v V) (V, bool) {
+	var zero V
+
+	vIndex, ok := buf.VValues.Lookup(v)
+	if !ok {
+		return zero, false
+	}
+
+	root, ok := buf.TryFind(vIndex)
+	if !ok {
+		return zero, false
+	}
+
+	return buf.VValues.At(root), true
+}
+
+// UsConnected reports whether U elements u1 and u2 are associated with the same V root.
+// U elements that have not been added are not connected to anything
+// and are not added by the check.
+func (buf *BipartiteUnionFindWithValues[U, V]) UsConnected(u1, u2 U) bool {
+	uIndex1, ok1 := buf.UValues.Lookup(u1)
+	uIndex2, ok2 := buf.UValues.Lookup(u2)
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	root1, ok1 := buf.FindAssociatedRoot(uIndex1)
+	root2, ok2 := buf.FindAssociatedRoot(uIndex2)
+
+	return ok1 && ok2 && root1 == root2
+}
+
+// VsConnected reports whether V elements v1 and v2 are in the same set.
+// V elements that have not been added are not connected to anything
+// and are not added by the check.
+func (buf *BipartiteUnionFindWithValues[U, V]) VsConnected(v1, v2 V) bool {
+	vIndex1, ok1 := buf.VValues.Lookup(v1)
+	vIndex2, ok2 := buf.VValues.Lookup(v2)
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	return buf.Connected(vIndex1, vIndex2)
+}
+
+// SetRootCmp reports the smallest V member of each set by cmp as its root,
+// falling back to the value that was seen first for members that compare equal.
+// Functions can't be encoded, so MarshalBinary returns ErrRootCmpNotEncodable
+// until another policy is set with SetRootPolicy.
+func (buf *BipartiteUnionFindWithValues[U, V]) SetRootCmp(cmp func(a, b V) int) {
+	buf.setPreference(cmpRoot, func(a, b int) bool {
+		c := cmp(buf.VValues.At(a), buf.VValues.At(b))
+		return c < 0 || (c == 0 && a < b)
+	})
+}
+
 // Union connects U and V elements, returning the root index
 func (buf *BipartiteUnionFindWithValues[U, V]) Union(u U, v V) int {
 	uIndex := buf.UValues.FetchIndex(u)
@@ -44,6 +145,18 @@ func (buf *BipartiteUnionFindWithValues[U, V]) Union(u U, v V) int {
 	return buf.BipartiteUnionFind.Union(uIndex, vIndex)
 }
 
+// UnionAll connects the U and V elements of every relation.
+// Values are enumerated up front, then the unions are split between workers
+// goroutines as described in UnionFind.UnionAll.
+func (buf *BipartiteUnionFindWithValues[U, V]) UnionAll(relations []Relation[U, V], workers int) {
+	edges := make([][2]int, len(relations))
+	for i, relation := range relations {
+		edges[i] = [2]int{buf.UValues.FetchIndex(relation.U), buf.VValues.FetchIndex(relation.V)}
+	}
+
+	buf.BipartiteUnionFind.UnionAll(edges, workers)
+}
+
 // UnionReturningValue connects U and V elements and returns the root value.
 // This is handy for directly getting new root value, but is slower
 // because it looks up value by index.
@@ -52,8 +165,104 @@ func (buf *BipartiteUnionFindWithValues[U, V]) UnionReturningValue(u U, v V) V {
 	return buf.VValues.At(idx)
 }
 
+// RejectedEdges returns the relations whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (buf *BipartiteUnionFindWithValues[U, V]) RejectedEdges() []Relation[U, V] {
+	return buf.relations(buf.rejected)
+}
+
 // FindReturningValue finds the root and returns it as a value
 func (buf *BipartiteUnionFindWithValues[U, V]) FindReturningValue(v V) V {
 	vIndex := buf.VValues.FetchIndex(v)
 	return buf.VValues.At(buf.Find(vIndex))
 }
+
+// Members returns all V values in the set containing the given V value.
+// V values that have not been added have no members and are not added by the call.
+func (buf *BipartiteUnionFindWithValues[U, V]) Members(v V) []V {
+	vIndex, ok := buf.VValues.Lookup(v)
+	if !ok {
+		return nil
+	}
+
+	return buf.VValues.AtEach(buf.UnionFind.Members(vIndex))
+}
+
+// Roots returns an iterator over the root V value of every set
+func (buf *BipartiteUnionFindWithValues[U, V]) Roots() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for root := range buf.UnionFind.Roots() {
+			if !yield(buf.VValues.At(root)) {
+				return
+			}
+		}
+	}
+}
+
+// Sets returns an iterator over every set of V values, yielding its root value
+// along with the V values of all of its members
+func (buf *BipartiteUnionFindWithValues[U, V]) Sets() iter.Seq2[V, []V] {
+	return func(yield func(V, []V) bool) {
+		for root, members := range buf.UnionFind.Sets() {
+			if !yield(buf.VValues.At(root), buf.VValues.AtEach(members)) {
+				return
+			}
+		}
+	}
+}
+
+// UsForVRoot returns every U value associated with the set containing the V value v.
+// V values that have not been added have no Us and are not added by the call.
+func (buf *BipartiteUnionFindWithValues[U, V]) UsForVRoot(v V) []U {
+	vIndex, ok := buf.VValues.Lookup(v)
+	if !ok {
+		return nil
+	}
+
+	return buf.UValues.AtEach(buf.AssociatedUs(vIndex))
+}
+
+// VsForVRoot returns every V value in the set containing the V value v.
+// V values that have not been added have no set and are not added by the call.
+func (buf *BipartiteUnionFindWithValues[U, V]) VsForVRoot(v V) []V {
+	vIndex, ok := buf.VValues.Lookup(v)
+	if !ok {
+		return nil
+	}
+
+	return buf.VValues.AtEach(buf.UnionFind.Members(vIndex))
+}
+
+// Clusters returns an iterator over every set of V values
+// along with the U values associated with it, in the same order as Sets
+func (buf *BipartiteUnionFindWithValues[U, V]) Clusters() iter.Seq[Cluster[U, V]] {
+	return func(yield func(Cluster[U, V]) bool) {
+		us := buf.usByRoot()
+		for root, members := range buf.UnionFind.Sets() {
+			cluster := Cluster[U, V]{
+				Root: buf.VValues.At(root),
+				Us:   buf.UValues.AtEach(us[root]),
+				Vs:   buf.VValues.AtEach(members),
+			}
+			if !yield(cluster) {
+				return
+			}
+		}
+	}
+}
+
+// SizeOf returns the exact number of V values in the set containing the given V value,
+// 0 if the V value has not been added. V values are not added by the call.
+func (buf *BipartiteUnionFindWithValues[U, V]) SizeOf(v V) int {
+	vIndex, ok := buf.VValues.Lookup(v)
+	if !ok {
+		return 0
+	}
+
+	return buf.Size(vIndex)
+}
+
+// LargestSets returns the root V values of the k largest sets, largest first
+func (buf *BipartiteUnionFindWithValues[U, V]) LargestSets(k int) []V {
+	return buf.VValues.AtEach(buf.UnionFind.LargestSets(k))
+}
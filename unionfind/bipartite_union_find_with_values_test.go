@@ -31,6 +31,149 @@ func TestBipartiteUnionFindWithValues(t *testing.T) {
 		root = uf.UnionReturningValue("D", 1)
 		assert.Equal(t, 1, root)
 	})
+
+	t.Run("Members()/Sets()/Roots()", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFindWithValues[string, int](0)
+		uf.Union("A", 1)
+		uf.Union("A", 2)
+		uf.Union("B", 2)
+		uf.Union("B", 3)
+		uf.Union("C", 4)
+
+		assert.Equal(t, []int{1, 2, 3}, uf.Members(3))
+
+		var roots []int
+		for root := range uf.Roots() {
+			roots = append(roots, root)
+		}
+		assert.Equal(t, []int{1, 4}, roots)
+
+		sets := make(map[int][]int)
+		for root, members := range uf.Sets() {
+			sets[root] = members
+		}
+		assert.Equal(t, map[int][]int{
+			1: {1, 2, 3},
+			4: {4},
+		}, sets)
+	})
+
+	t.Run("SizeOf()/LargestSets()", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFindWithValues[string, int](0)
+		uf.Union("A", 1)
+		uf.Union("A", 2)
+		uf.Union("B", 2)
+		uf.Union("B", 3)
+		uf.Union("C", 4)
+		uf.Union("C", 5)
+
+		assert.Equal(t, 3, uf.SizeOf(2))
+		assert.Equal(t, 2, uf.SizeOf(5))
+		assert.Equal(t, 0, uf.SizeOf(6))
+		assert.Nil(t, uf.Members(6))
+		assert.False(t, uf.ContainsV(6), "SizeOf and Members should not add V values")
+		assert.Equal(t, []int{1, 4}, uf.LargestSets(2))
+	})
+
+	t.Run("ContainsU()/ContainsV()/TryFind...()", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFindWithValues[string, int](0)
+		uf.Union("A", 1)
+		uf.Union("A", 2)
+
+		root, ok := uf.TryFindVRootForU("A")
+		assert.True(t, ok)
+		assert.Equal(t, 1, root)
+		root, ok = uf.TryFindReturningValue(2)
+		assert.True(t, ok)
+		assert.Equal(t, 1, root)
+		assert.True(t, uf.ContainsU("A"))
+		assert.True(t, uf.ContainsV(2))
+
+		_, ok = uf.TryFindVRootForU("B")
+		assert.False(t, ok)
+		_, ok = uf.TryFindReturningValue(3)
+		assert.False(t, ok)
+		assert.False(t, uf.ContainsU("B"))
+		assert.False(t, uf.ContainsV(3))
+		assert.Equal(t, 2, uf.RootCount, "lookups should not add values")
+	})
+
+	t.Run("UsConnected()/VsConnected()", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFindWithValues[string, int](0)
+		uf.Union("A", 1)
+		uf.Union("A", 2)
+		uf.Union("B", 2)
+		uf.Union("C", 3)
+
+		assert.True(t, uf.UsConnected("A", "B"))
+		assert.False(t, uf.UsConnected("A", "C"))
+		assert.False(t, uf.UsConnected("A", "D"))
+		assert.True(t, uf.VsConnected(1, 2))
+		assert.False(t, uf.VsConnected(1, 3))
+		assert.False(t, uf.VsConnected(1, 4))
+		assert.False(t, uf.ContainsU("D"), "UsConnected should not add values")
+		assert.False(t, uf.ContainsV(4), "VsConnected should not add values")
+	})
+
+	t.Run("UnionAll()", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFindWithValues[string, int](0)
+		uf.UnionAll([]unionfind.Relation[string, int]{
+			{U: "A", V: 1},
+			{U: "A", V: 2},
+			{U: "B", V: 2},
+			{U: "B", V: 3},
+			{U: "C", V: 4},
+		}, 2)
+
+		assert.True(t, uf.UsConnected("A", "B"))
+		assert.False(t, uf.UsConnected("A", "C"))
+		assert.True(t, uf.VsConnected(1, 3))
+		root, ok := uf.FindVRootForU("C")
+		assert.True(t, ok)
+		assert.Equal(t, 4, root)
+	})
+
+	t.Run("maximum set size", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.SetMaxSetSize(2)
+		buf.Union("office-ip", "alice")
+		buf.Union("office-ip", "bob")
+		buf.Union("office-ip", "carol")
+
+		assert.Equal(t, []unionfind.Relation[string, string]{{U: "office-ip", V: "carol"}}, buf.RejectedEdges())
+		assert.True(t, buf.VsConnected("alice", "bob"))
+		assert.False(t, buf.VsConnected("alice", "carol"))
+	})
+
+	t.Run("UsForVRoot()/VsForVRoot()/Clusters()", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		uf.Union("alice", "chess club")
+		uf.Union("alice", "tennis club")
+		uf.Union("bob", "tennis club")
+		uf.Union("carol", "book club")
+
+		assert.ElementsMatch(t, []string{"alice", "bob"}, uf.UsForVRoot("tennis club"))
+		assert.ElementsMatch(t, []string{"chess club", "tennis club"}, uf.VsForVRoot("chess club"))
+		assert.Equal(t, []string{"carol"}, uf.UsForVRoot("book club"))
+
+		assert.Nil(t, uf.UsForVRoot("golf club"))
+		assert.Nil(t, uf.VsForVRoot("golf club"))
+		assert.False(t, uf.ContainsV("golf club"))
+
+		var clusters []unionfind.Cluster[string, string]
+		for cluster := range uf.Clusters() {
+			clusters = append(clusters, cluster)
+		}
+		assert.Equal(t, []unionfind.Cluster[string, string]{
+			{Root: "chess club", Us: []string{"alice", "bob"}, Vs: []string{"chess club", "tennis club"}},
+			{Root: "book club", Us: []string{"carol"}, Vs: []string{"book club"}},
+		}, clusters)
+
+		for cluster := range uf.Clusters() {
+			assert.Equal(t, "chess club", cluster.Root)
+			break
+		}
+	})
 }
 
 func BenchmarkBipartiteUnionFindWithValues(b *testing.B) {
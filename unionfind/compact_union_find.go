@@ -0,0 +1,654 @@
+package unionfind
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+)
+
+// maxCompactIndex is the largest index a CompactUnionFind can hold,
+// since parents are stored offset by one in an int32
+const maxCompactIndex = math.MaxInt32 - 1
+
+// CompactUnionFind is a union-find structure with the API of UnionFind
+// that stores each element in a single int32 rather than
+// an int parent, an int rank and a bool, taking 4 bytes per element instead of 17 on 64-bit platforms.
+// Indices must be less than math.MaxInt32.
+// Root policies other than RankRoot add another 4 bytes per element, FirstSeenRoot 8,
+// and rollback, provenance and edge retention keep their records on the heap as for UnionFind.
+// UnionAll always unions one edge at a time.
+type CompactUnionFind struct {
+	// Each element by index: 0 if it hasn't been added,
+	// minus the size of its set for roots,
+	// or its parent's index plus one otherwise
+	Parent    []int32
+	RootCount int // Number of elements that have been added, as for UnionFind
+	// Number of sets with more than one member
+	NonSingletonCount int
+
+	// Changes recorded in rollback mode
+	changeLog
+
+	// Which member of each set is reported as its root, see SetRootPolicy
+	rootPolicy RootPolicy
+	// Whether member a should be reported as the root over member b, nil for RankRoot
+	prefer func(a, b int) bool
+	// Member reported as the root by root index, nil for RankRoot
+	representative []int32
+	// Order elements were added in by index, only kept for FirstSeenRoot
+	addedAt []int32
+
+	// Largest set a union may create, 0 for no limit, see SetMaxSetSize
+	maxSetSize int
+	// Edges whose union was rejected for exceeding maxSetSize
+	rejected [][2]int
+
+	// Unions that merged sets, nil unless provenance is enabled
+	provenance *provenance
+	// Every edge passed to Union, nil unless edge retention is enabled
+	retained *retainedEdges
+
+	// Makes room in Parent for elements up to index n, nil to grow it on the heap
+	grow func(n int)
+}
+
+// NewCompactUnionFind creates a new CompactUnionFind with the specified capacity
+func NewCompactUnionFind(capacity int) *CompactUnionFind {
+	return &CompactUnionFind{Parent: make([]int32, capacity)}
+}
+
+// growTo makes room for elements up to index n
+func (uf *CompactUnionFind) growTo(n int) {
+	if n >= len(uf.Parent) {
+		if uf.grow != nil {
+			uf.grow(n)
+		} else {
+			uf.Parent = expandSlice(uf.Parent, n)
+		}
+	}
+
+	if uf.representative != nil {
+		uf.representative = expandSlice(uf.representative, len(uf.Parent)-1)
+	}
+	if uf.addedAt != nil {
+		uf.addedAt = expandSlice(uf.addedAt, len(uf.Parent)-1)
+	}
+}
+
+func (uf *CompactUnionFind) addElement(n int) {
+	if n < 0 || n > maxCompactIndex {
+		panic(fmt.Sprintf("unionfind: index %d out of range for CompactUnionFind", n))
+	}
+
+	if n >= len(uf.Parent) {
+		uf.growTo(n)
+	}
+
+	if uf.Parent[n] == 0 {
+		uf.Parent[n] = -1
+		if uf.representative != nil {
+			uf.representative[n] = int32(n) //nolint:gosec // indices are checked against maxCompactIndex above
+		}
+		if uf.addedAt != nil {
+			uf.addedAt[n] = int32(uf.RootCount) //nolint:gosec // there are no more elements than indices
+		}
+		uf.RootCount++
+		uf.record(change{index: n, parent: -1})
+	}
+}
+
+// Find returns the root of the set containing the given index
+func (uf *CompactUnionFind) Find(index int) int {
+	return uf.representativeOf(uf.findRoot(index))
+}
+
+// findRoot returns the root of the tree containing the given index,
+// which is only reported as the root of the set under RankRoot
+func (uf *CompactUnionFind) findRoot(index int) int {
+	uf.addElement(index)
+
+	root := index
+	for uf.Parent[root] > 0 {
+		root = int(uf.Parent[root]) - 1
+	}
+
+	// Compressed paths can't be rolled back
+	if uf.rollback {
+		return root
+	}
+
+	for uf.Parent[index] > 0 {
+		next := int(uf.Parent[index]) - 1
+		uf.Parent[index] = int32(root + 1) //nolint:gosec // indices are checked against maxCompactIndex when added
+		index = next
+	}
+
+	return root
+}
+
+// Contains reports whether the element at the given index has been added
+func (uf *CompactUnionFind) Contains(index int) bool {
+	return index >= 0 && index < len(uf.Parent) && uf.Parent[index] != 0
+}
+
+// TryFind returns the root of the set containing the given index
+// and false if the element has not been added.
+// Unlike Find it never adds elements or compresses paths,
+// so it leaves the structure unchanged.
+func (uf *CompactUnionFind) TryFind(index int) (int, bool) {
+	root, ok := uf.tryFindRoot(index)
+	if !ok {
+		return -1, false
+	}
+
+	return uf.representativeOf(root), true
+}
+
+// tryFindRoot returns the root of the tree containing the given index
+// and false if the element has not been added, leaving the structure unchanged
+func (uf *CompactUnionFind) tryFindRoot(index int) (int, bool) {
+	if !uf.Contains(index) {
+		return -1, false
+	}
+
+	for uf.Parent[index] > 0 {
+		index = int(uf.Parent[index]) - 1
+	}
+
+	return index, true
+}
+
+// Connected reports whether a and b are in the same set.
+// Elements that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *CompactUnionFind) Connected(a, b int) bool {
+	if !uf.Contains(a) || !uf.Contains(b) {
+		return false
+	}
+
+	return uf.findRoot(a) == uf.findRoot(b)
+}
+
+// Union merges the sets containing a and b, returning the root of the merged set.
+// If the merged set would be larger than the maximum set size the edge is
+// recorded in RejectedEdges instead, and the root of a's set is returned.
+func (uf *CompactUnionFind) Union(a, b int) int {
+	separate := uf.provenance != nil && uf.findRoot(a) != uf.findRoot(b)
+
+	root, ok := uf.union(a, b)
+	if !ok {
+		uf.rejected = append(uf.rejected, [2]int{a, b})
+		return root
+	}
+
+	if separate {
+		uf.provenance.record([2]int{a, b}, a, b)
+	}
+	uf.retain(a, b)
+
+	return root
+}
+
+// union merges the sets containing a and b, returning the root of the merged set
+// and false without merging if it would be larger than the maximum set size
+func (uf *CompactUnionFind) union(a, b int) (int, bool) {
+	rootA := uf.findRoot(a)
+	rootB := uf.findRoot(b)
+	if rootA == rootB {
+		return uf.representativeOf(rootA), true
+	}
+
+	sizeA, sizeB := uf.size(rootA), uf.size(rootB)
+	if uf.maxSetSize > 0 && sizeA+sizeB > uf.maxSetSize {
+		return uf.representativeOf(rootA), false
+	}
+
+	uf.NonSingletonCount++
+	if sizeA > 1 {
+		uf.NonSingletonCount--
+	}
+	if sizeB > 1 {
+		uf.NonSingletonCount--
+	}
+
+	// Make the smaller tree a child of the larger one
+	if sizeA < sizeB {
+		uf.link(rootA, rootB)
+		return uf.representativeOf(rootB), true
+	}
+
+	uf.link(rootB, rootA)
+	return uf.representativeOf(rootA), true
+}
+
+// link makes root a child of parent
+func (uf *CompactUnionFind) link(root, parent int) {
+	c := change{index: root, parent: parent, size: uf.size(root)}
+
+	uf.Parent[parent] -= int32(c.size)  //nolint:gosec // set sizes are bounded by the number of indices
+	uf.Parent[root] = int32(parent + 1) //nolint:gosec // indices are checked against maxCompactIndex when added
+	if uf.representative != nil {
+		c.representative = int(uf.representative[parent])
+		preferred := uf.preferredOf(int(uf.representative[parent]), int(uf.representative[root]))
+		uf.representative[parent] = int32(preferred) //nolint:gosec // representatives are indices
+	}
+
+	uf.record(c)
+}
+
+// size returns the size of the set rooted at root
+func (uf *CompactUnionFind) size(root int) int {
+	return -int(uf.Parent[root])
+}
+
+// SetMaxSetSize limits the size of the sets unions may create.
+// Unions that would exceed it are rejected and recorded in RejectedEdges.
+// Sets that are already larger are left as they are.
+// A size of 0 or less removes the limit.
+func (uf *CompactUnionFind) SetMaxSetSize(size int) {
+	uf.maxSetSize = max(size, 0)
+}
+
+// MaxSetSize returns the largest set a union may create, 0 if there's no limit
+func (uf *CompactUnionFind) MaxSetSize() int {
+	return uf.maxSetSize
+}
+
+// RejectedEdges returns the edges whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (uf *CompactUnionFind) RejectedEdges() [][2]int {
+	return slices.Clone(uf.rejected)
+}
+
+// UnionAll merges the sets containing both ends of every edge.
+// The edges are always unioned one at a time in order, whatever the number of workers,
+// since a concurrent forest would need the full-width arrays the structure exists to avoid.
+func (uf *CompactUnionFind) UnionAll(edges [][2]int, _ int) {
+	for _, edge := range edges {
+		uf.Union(edge[0], edge[1])
+	}
+}
+
+// Size returns the exact number of elements in the set containing the given index,
+// or 0 if the element has not been added
+func (uf *CompactUnionFind) Size(index int) int {
+	root, ok := uf.tryFindRoot(index)
+	if !ok {
+		return 0
+	}
+
+	return uf.size(root)
+}
+
+// LargestSets returns the root indices of the k largest sets, largest first.
+// Sets of equal size are ordered by the index of their tree root.
+func (uf *CompactUnionFind) LargestSets(k int) []int {
+	if k <= 0 {
+		return nil
+	}
+
+	roots := slices.Collect(uf.treeRoots())
+	slices.SortStableFunc(roots, func(a, b int) int {
+		return cmp.Compare(uf.size(b), uf.size(a))
+	})
+
+	if k < len(roots) {
+		roots = roots[:k]
+	}
+	for i, root := range roots {
+		roots[i] = uf.representativeOf(root)
+	}
+
+	return roots
+}
+
+// Members returns the indices of all elements in the set containing index,
+// or nil if the element has not been added. The structure is left unchanged.
+func (uf *CompactUnionFind) Members(index int) []int {
+	root, ok := uf.tryFindRoot(index)
+	if !ok {
+		return nil
+	}
+
+	var members []int
+	for i := range uf.Parent {
+		if memberRoot, ok := uf.tryFindRoot(i); ok && memberRoot == root {
+			members = append(members, i)
+		}
+	}
+
+	return members
+}
+
+// Roots returns an iterator over the root index of every set
+func (uf *CompactUnionFind) Roots() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for root := range uf.treeRoots() {
+			if !yield(uf.representativeOf(root)) {
+				return
+			}
+		}
+	}
+}
+
+func (uf *CompactUnionFind) treeRoots() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i, parent := range uf.Parent {
+			if parent < 0 && !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// Sets returns an iterator over every set, yielding its root index
+// along with the indices of all of its members.
+// Sets are yielded in ascending order of the index of their tree root,
+// which is their root index under RankRoot. The structure is left unchanged.
+func (uf *CompactUnionFind) Sets() iter.Seq2[int, []int] {
+	return func(yield func(int, []int) bool) {
+		members := make(map[int][]int)
+		for i := range uf.Parent {
+			if root, ok := uf.tryFindRoot(i); ok {
+				members[root] = append(members[root], i)
+			}
+		}
+
+		for root := range uf.treeRoots() {
+			if !yield(uf.representativeOf(root), members[root]) {
+				return
+			}
+		}
+	}
+}
+
+// SetRootPolicy sets which member of each set is reported as its root.
+// Elements that already exist when switching to FirstSeenRoot
+// are taken to have been added in index order.
+func (uf *CompactUnionFind) SetRootPolicy(policy RootPolicy) {
+	uf.addedAt = nil
+	if policy == FirstSeenRoot {
+		uf.addedAt = make([]int32, len(uf.Parent))
+		var added int32
+		for i, parent := range uf.Parent {
+			if parent != 0 {
+				uf.addedAt[i] = added
+				added++
+			}
+		}
+	}
+
+	if policy != MinIndexRoot && policy != FirstSeenRoot {
+		uf.rootPolicy = RankRoot
+		uf.prefer = nil
+		uf.representative = nil
+		return
+	}
+
+	uf.rootPolicy = policy
+	uf.prefer = uf.preferenceFor(policy)
+	uf.representative = make([]int32, len(uf.Parent))
+	for root := range uf.treeRoots() {
+		uf.representative[root] = int32(root) //nolint:gosec // indices are checked against maxCompactIndex when added
+	}
+	for i := range uf.Parent {
+		if root, ok := uf.tryFindRoot(i); ok {
+			uf.representative[root] = int32(uf.preferredOf(int(uf.representative[root]), i)) //nolint:gosec // representatives are indices
+		}
+	}
+}
+
+// preferenceFor returns the preference of the built in policies
+func (uf *CompactUnionFind) preferenceFor(policy RootPolicy) func(a, b int) bool {
+	switch policy {
+	case MinIndexRoot:
+		return func(a, b int) bool { return a < b }
+	case FirstSeenRoot:
+		return func(a, b int) bool {
+			return uf.addedAt[a] < uf.addedAt[b] || (uf.addedAt[a] == uf.addedAt[b] && a < b)
+		}
+	default:
+		return nil
+	}
+}
+
+// representativeOf returns the member reported as the root of the tree rooted at root
+func (uf *CompactUnionFind) representativeOf(root int) int {
+	if uf.representative == nil {
+		return root
+	}
+
+	return int(uf.representative[root])
+}
+
+// preferredOf returns whichever of members a and b is preferred as a root,
+// a if there's no preference
+func (uf *CompactUnionFind) preferredOf(a, b int) int {
+	if uf.prefer != nil && uf.prefer(b, a) {
+		return b
+	}
+
+	return a
+}
+
+// EnableRollback switches to rollback mode, turning off path compression
+// and recording changes so they can be undone with Checkpoint and Rollback
+func (uf *CompactUnionFind) EnableRollback() {
+	uf.rollback = true
+}
+
+// DisableRollback leaves rollback mode, discarding the recorded changes
+// and turning path compression back on. Existing checkpoints become stale.
+func (uf *CompactUnionFind) DisableRollback() {
+	uf.rollback = false
+	uf.discardChanges()
+}
+
+// Checkpoint returns a checkpoint of the current state for Rollback,
+// switching to rollback mode if it isn't enabled yet
+func (uf *CompactUnionFind) Checkpoint() Checkpoint {
+	uf.EnableRollback()
+	return uf.checkpoint(len(uf.rejected), uf.provenance)
+}
+
+// Rollback restores the exact state at the given checkpoint,
+// undoing every union and removing every element added since,
+// along with the edges rejected, recorded for provenance or retained since.
+// Checkpoints taken after the given checkpoint become stale.
+// It returns ErrStaleCheckpoint without changing anything if the checkpoint is stale.
+func (uf *CompactUnionFind) Rollback(checkpoint Checkpoint) error {
+	if err := uf.checkCheckpoint(checkpoint); err != nil {
+		return err
+	}
+
+	uf.rejected = uf.rejected[:min(checkpoint.rejected, len(uf.rejected))]
+	if uf.provenance != nil {
+		uf.provenance.truncate(checkpoint.provenance)
+	}
+
+	for len(uf.changes) > checkpoint.changes {
+		c := uf.changes[len(uf.changes)-1]
+		uf.changes = uf.changes[:len(uf.changes)-1]
+
+		if c.retained {
+			uf.retained.remove(c.index, c.parent)
+			continue
+		}
+
+		if c.parent < 0 {
+			uf.Parent[c.index] = 0
+			uf.RootCount--
+			continue
+		}
+
+		uf.Parent[c.index] = int32(-c.size)  //nolint:gosec // set sizes are bounded by the number of indices
+		uf.Parent[c.parent] += int32(c.size) //nolint:gosec // set sizes are bounded by the number of indices
+		if uf.representative != nil {
+			uf.representative[c.parent] = int32(c.representative) //nolint:gosec // representatives are indices
+		}
+
+		uf.NonSingletonCount--
+		if c.size > 1 {
+			uf.NonSingletonCount++
+		}
+		if uf.size(c.parent) > 1 {
+			uf.NonSingletonCount++
+		}
+	}
+
+	return nil
+}
+
+// EnableProvenance starts recording the unions that merge sets so Explain
+// can tell why two elements are connected, see UnionFind.EnableProvenance
+func (uf *CompactUnionFind) EnableProvenance() {
+	if uf.provenance == nil {
+		uf.provenance = newProvenance()
+	}
+}
+
+// DisableProvenance stops recording unions and forgets the ones recorded
+func (uf *CompactUnionFind) DisableProvenance() {
+	uf.provenance = nil
+}
+
+// Explain returns the chain of edges passed to Union that connects a to b,
+// each as it was passed, and false if they aren't connected
+// or provenance wasn't enabled when they were.
+// Elements that have not been added are not added by the call.
+func (uf *CompactUnionFind) Explain(a, b int) ([][2]int, bool) {
+	if uf.provenance == nil || !uf.Connected(a, b) {
+		return nil, false
+	}
+
+	return uf.provenance.path(a, b)
+}
+
+// EnableEdgeRetention starts keeping every edge passed to Union
+// so that RemoveEdge and RemoveElement can split sets again, see UnionFind.EnableEdgeRetention
+func (uf *CompactUnionFind) EnableEdgeRetention() {
+	if uf.retained == nil {
+		uf.retained = newRetainedEdges()
+	}
+}
+
+// retain adds the edge between a and b to the retained edges when edge retention is enabled
+func (uf *CompactUnionFind) retain(a, b int) {
+	if uf.retained == nil || a == b {
+		return
+	}
+
+	uf.retained.add(a, b, 1)
+	uf.record(change{index: a, parent: b, retained: true})
+}
+
+// RemoveEdge removes one copy of the edge between a and b, as passed to Union in either order,
+// splitting their set if nothing else connects them. It returns false if there's no such edge
+// or edge retention isn't enabled.
+// Removals can't be rolled back, so checkpoints taken before one become stale.
+func (uf *CompactUnionFind) RemoveEdge(a, b int) bool {
+	if uf.retained == nil || !uf.retained.remove(a, b) {
+		return false
+	}
+
+	members, tree := uf.retained.component(a)
+	if slices.Contains(members, b) {
+		// The edge may have been part of the forest
+		uf.explain(members, tree)
+		return true
+	}
+
+	uf.dissolve(uf.findRoot(a))
+	uf.regroup(members, tree)
+	uf.regroup(uf.retained.component(b))
+	return true
+}
+
+// RemoveElement removes the element at index along with every edge touching it,
+// splitting its set into the parts that are still connected.
+// It returns false if the element has not been added or edge retention isn't enabled.
+// Under FirstSeenRoot it also renumbers the order every element was added in.
+// Removals can't be rolled back, so checkpoints taken before one become stale.
+func (uf *CompactUnionFind) RemoveElement(index int) bool {
+	if uf.retained == nil || !uf.Contains(index) {
+		return false
+	}
+
+	uf.dissolve(uf.findRoot(index))
+	uf.Parent[index] = 0
+	uf.RootCount--
+	if uf.addedAt != nil {
+		// Keep the order elements were added in dense so elements added later follow the rest
+		for i, added := range uf.addedAt {
+			if uf.Parent[i] != 0 && added > uf.addedAt[index] {
+				uf.addedAt[i]--
+			}
+		}
+	}
+	if uf.provenance != nil {
+		uf.provenance.forget([]int{index})
+	}
+
+	regrouped := make(map[int]bool)
+	for _, neighbor := range uf.retained.removeElement(index) {
+		if regrouped[neighbor] {
+			continue
+		}
+
+		members, tree := uf.retained.component(neighbor)
+		for _, member := range members {
+			regrouped[member] = true
+		}
+		uf.regroup(members, tree)
+	}
+
+	uf.rejected = slices.DeleteFunc(uf.rejected, func(edge [2]int) bool {
+		return edge[0] == index || edge[1] == index
+	})
+	return true
+}
+
+// dissolve forgets the set rooted at root ahead of its members being regrouped
+func (uf *CompactUnionFind) dissolve(root int) {
+	if uf.size(root) > 1 {
+		uf.NonSingletonCount--
+	}
+	if uf.rollback {
+		uf.discardChanges()
+	}
+}
+
+// regroup makes members a single flat set rooted at its first member,
+// recording tree as the unions that merged it
+func (uf *CompactUnionFind) regroup(members []int, tree [][2]int) {
+	root := members[0]
+	representative := root
+	for _, member := range members {
+		uf.Parent[member] = int32(root + 1) //nolint:gosec // indices are checked against maxCompactIndex when added
+		representative = uf.preferredOf(representative, member)
+	}
+
+	uf.Parent[root] = -int32(len(members)) //nolint:gosec // set sizes are bounded by the number of indices
+	if len(members) > 1 {
+		uf.NonSingletonCount++
+	}
+	if uf.representative != nil {
+		uf.representative[root] = int32(representative) //nolint:gosec // representatives are indices
+	}
+
+	uf.explain(members, tree)
+}
+
+// explain replaces the recorded unions of members with tree when provenance is enabled
+func (uf *CompactUnionFind) explain(members []int, tree [][2]int) {
+	if uf.provenance == nil {
+		return
+	}
+
+	uf.provenance.forget(members)
+	for _, edge := range tree {
+		uf.provenance.record(edge, edge[0], edge[1])
+	}
+}
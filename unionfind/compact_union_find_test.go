@@ -0,0 +1,343 @@
+package unionfind_test
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactUnionFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Union", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		assert.Equal(t, 1, uf.Union(1, 2))
+		assert.Equal(t, 1, uf.Union(2, 3))
+		assert.Equal(t, 5, uf.Union(5, 6))
+		assert.Equal(t, 1, uf.Union(6, 1))
+		assert.Equal(t, 1, uf.Find(5))
+		assert.Equal(t, 5, uf.Size(6))
+		assert.True(t, uf.Connected(2, 6))
+		assert.False(t, uf.Connected(2, 7))
+		assert.False(t, uf.Contains(7), "Connected doesn't add elements")
+
+		uf.Find(7)
+		assert.Equal(t, 6, uf.RootCount)
+		assert.Equal(t, 1, uf.NonSingletonCount)
+		assert.Equal(t, []int{1, 2, 3, 5, 6}, uf.Members(3))
+		assert.Equal(t, []int{1, 7}, slices.Collect(uf.Roots()))
+		assert.Empty(t, uf.LargestSets(0))
+		assert.Equal(t, []int{1}, uf.LargestSets(1))
+
+		root, ok := uf.TryFind(3)
+		require.True(t, ok)
+		assert.Equal(t, 1, root)
+		_, ok = uf.TryFind(8)
+		assert.False(t, ok)
+
+		assert.Nil(t, uf.Members(8))
+		assert.Equal(t, 0, uf.Size(8))
+		assert.False(t, uf.Contains(8), "Members and Size don't add elements")
+	})
+
+	t.Run("matches UnionFind", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 1000
+
+		uf := unionfind.NewUnionFind(0)
+		compact := unionfind.NewCompactUnionFind(0)
+		for range n {
+			a, b := rng.Intn(n), rng.Intn(n)
+			require.Equal(t, uf.Union(a, b), compact.Union(a, b))
+		}
+
+		assert.Equal(t, uf.RootCount, compact.RootCount)
+		assert.Equal(t, uf.NonSingletonCount, compact.NonSingletonCount)
+		assert.Equal(t, uf.LargestSets(10), compact.LargestSets(10))
+		for i := range n {
+			require.Equal(t, uf.Contains(i), compact.Contains(i))
+			if uf.Contains(i) {
+				assert.Equal(t, uf.Find(i), compact.Find(i))
+				assert.Equal(t, uf.Size(i), compact.Size(i))
+			}
+		}
+
+		sets := make(map[int][]int)
+		for root, members := range compact.Sets() {
+			sets[root] = members
+		}
+		for root, members := range uf.Sets() {
+			assert.Equal(t, members, sets[root])
+		}
+	})
+
+	t.Run("max set size", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		uf.SetMaxSetSize(2)
+		uf.UnionAll([][2]int{{0, 1}, {1, 2}, {3, 4}}, 0)
+
+		assert.Equal(t, 2, uf.MaxSetSize())
+		assert.False(t, uf.Connected(1, 2))
+		assert.True(t, uf.Connected(3, 4))
+		assert.Equal(t, [][2]int{{1, 2}}, uf.RejectedEdges())
+	})
+
+	t.Run("Members and Sets leave the structure unchanged", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		uf.Union(0, 1)
+		uf.Union(2, 3)
+		uf.Union(0, 2)
+		parents := slices.Clone(uf.Parent)
+
+		assert.Equal(t, []int{0, 1, 2, 3}, uf.Members(3))
+		for root, members := range uf.Sets() {
+			assert.Equal(t, 0, root)
+			assert.Equal(t, []int{0, 1, 2, 3}, members)
+		}
+		assert.Equal(t, parents, uf.Parent)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		uf.SetMaxSetSize(3)
+		uf.Union(0, 1)
+		checkpoint := uf.Checkpoint()
+		parents := slices.Clone(uf.Parent)
+
+		uf.Union(2, 3)
+		uf.Union(1, 2)
+		uf.Union(4, 0)
+		assert.Equal(t, [][2]int{{1, 2}}, uf.RejectedEdges())
+
+		require.NoError(t, uf.Rollback(checkpoint))
+		assert.Equal(t, parents, uf.Parent[:len(parents)])
+		assert.Equal(t, 2, uf.RootCount)
+		assert.Equal(t, 1, uf.NonSingletonCount)
+		assert.False(t, uf.Contains(2))
+		assert.Empty(t, uf.RejectedEdges())
+		require.NoError(t, uf.Rollback(checkpoint), "checkpoints can be rolled back to more than once")
+
+		uf.Union(5, 6)
+		stale := uf.Checkpoint()
+		require.NoError(t, uf.Rollback(checkpoint))
+		assert.ErrorIs(t, uf.Rollback(stale), unionfind.ErrStaleCheckpoint)
+	})
+
+	t.Run("root policy", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		uf.SetRootPolicy(unionfind.FirstSeenRoot)
+		uf.Union(5, 3)
+		uf.Union(1, 3)
+		uf.Union(2, 1)
+		assert.Equal(t, 5, uf.Find(2))
+		assert.Equal(t, []int{5}, slices.Collect(uf.Roots()))
+
+		uf.SetRootPolicy(unionfind.MinIndexRoot)
+		assert.Equal(t, 1, uf.Find(5))
+		assert.Equal(t, []int{1}, uf.LargestSets(1))
+
+		uf.SetRootPolicy(unionfind.RankRoot)
+		root, _ := uf.TryFind(2)
+		assert.Equal(t, uf.Find(5), root)
+	})
+
+	t.Run("provenance", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		uf.EnableProvenance()
+		uf.Union(0, 1)
+		uf.Union(2, 1)
+		uf.Union(0, 2)
+
+		path, ok := uf.Explain(0, 2)
+		require.True(t, ok)
+		assert.Equal(t, [][2]int{{0, 1}, {2, 1}}, path)
+		_, ok = uf.Explain(0, 3)
+		assert.False(t, ok)
+		assert.False(t, uf.Contains(3), "Explain doesn't add elements")
+
+		uf.DisableProvenance()
+		_, ok = uf.Explain(0, 2)
+		assert.False(t, ok)
+	})
+
+	t.Run("removal", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		assert.False(t, uf.RemoveEdge(0, 1), "edge retention isn't enabled")
+
+		uf.EnableEdgeRetention()
+		uf.UnionAll([][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}, {4, 5}}, 0)
+
+		assert.True(t, uf.RemoveEdge(1, 2))
+		assert.True(t, uf.Connected(1, 2), "still connected through 3 and 0")
+		assert.True(t, uf.RemoveEdge(3, 0))
+		assert.False(t, uf.Connected(1, 2))
+		assert.Equal(t, []int{0, 1}, uf.Members(0))
+		assert.Equal(t, []int{2, 3}, uf.Members(3))
+		assert.Equal(t, 3, uf.NonSingletonCount)
+		assert.False(t, uf.RemoveEdge(3, 0))
+
+		assert.True(t, uf.RemoveElement(4))
+		assert.False(t, uf.Contains(4))
+		assert.Equal(t, 1, uf.Size(5))
+		assert.Equal(t, 5, uf.RootCount)
+		assert.Equal(t, 2, uf.NonSingletonCount)
+		assert.False(t, uf.RemoveElement(4))
+	})
+
+	t.Run("encoding", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		uf.SetRootPolicy(unionfind.FirstSeenRoot)
+		uf.SetMaxSetSize(3)
+		uf.EnableProvenance()
+		uf.EnableEdgeRetention()
+		uf.UnionAll([][2]int{{4, 1}, {1, 2}, {2, 3}, {5, 6}}, 0)
+		checkpoint := uf.Checkpoint()
+		uf.Union(6, 7)
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		restored := &unionfind.CompactUnionFind{}
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, uf.Parent, restored.Parent)
+		assert.Equal(t, 4, restored.Find(2))
+		assert.Equal(t, uf.RejectedEdges(), restored.RejectedEdges())
+		path, ok := restored.Explain(4, 2)
+		require.True(t, ok)
+		assert.Equal(t, [][2]int{{4, 1}, {1, 2}}, path)
+
+		require.NoError(t, restored.Rollback(checkpoint))
+		assert.False(t, restored.Contains(7))
+		assert.True(t, restored.RemoveEdge(5, 6))
+		assert.False(t, restored.Connected(5, 6))
+
+		assert.ErrorIs(t, restored.UnmarshalBinary(data[:len(data)-1]), unionfind.ErrInvalidEncoding)
+		assert.True(t, restored.Contains(5), "a failed decode leaves the structure unchanged")
+	})
+
+	t.Run("encoding rejects cycles", func(t *testing.T) {
+		uf := &unionfind.CompactUnionFind{Parent: []int32{2, 1}, RootCount: 2}
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, (&unionfind.CompactUnionFind{}).UnmarshalBinary(data), unionfind.ErrInvalidEncoding)
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		uf.Union(0, 1)
+		other := unionfind.NewCompactUnionFind(0)
+		other.Union(1, 2)
+		other.Union(3, 4)
+		other.Find(5)
+		parents := slices.Clone(other.Parent)
+
+		uf.Merge(other)
+		assert.Equal(t, []int{0, 1, 2}, uf.Members(0))
+		assert.Equal(t, []int{3, 4}, uf.Members(4))
+		assert.True(t, uf.Contains(5))
+		assert.Equal(t, parents, other.Parent)
+	})
+
+	t.Run("matches UnionFind with root policies and rollback", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 200
+
+		for _, policy := range []unionfind.RootPolicy{unionfind.MinIndexRoot, unionfind.FirstSeenRoot} {
+			uf := unionfind.NewUnionFind(0)
+			compact := unionfind.NewCompactUnionFind(0)
+			uf.SetRootPolicy(policy)
+			compact.SetRootPolicy(policy)
+
+			for range 20 {
+				checkpoint, compactCheckpoint := uf.Checkpoint(), compact.Checkpoint()
+				for range 20 {
+					a, b := rng.Intn(n), rng.Intn(n)
+					require.Equal(t, uf.Union(a, b), compact.Union(a, b))
+				}
+				if rng.Intn(2) == 0 {
+					require.NoError(t, uf.Rollback(checkpoint))
+					require.NoError(t, compact.Rollback(compactCheckpoint))
+				}
+			}
+
+			assert.Equal(t, uf.RootCount, compact.RootCount)
+			assert.Equal(t, uf.NonSingletonCount, compact.NonSingletonCount)
+			for i := range n {
+				require.Equal(t, uf.Contains(i), compact.Contains(i))
+				if uf.Contains(i) {
+					assert.Equal(t, uf.Find(i), compact.Find(i))
+				}
+			}
+		}
+	})
+
+	t.Run("indices must fit in an int32", func(t *testing.T) {
+		uf := unionfind.NewCompactUnionFind(0)
+		assert.Panics(t, func() { uf.Find(-1) })
+		assert.Panics(t, func() { uf.Find(math.MaxInt32) })
+	})
+}
+
+func BenchmarkCompactUnionFind(b *testing.B) {
+	const n = 1000000
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+	edges := make([][2]int, n)
+	for i := range edges {
+		edges[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+	}
+
+	// Reports the heap each structure holds per element once the edges are unioned
+	bytesPerElement := func(b *testing.B, build func() any) {
+		b.Helper()
+
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		uf := build()
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(uf)
+
+		b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/n, "heap-B/element")
+	}
+
+	b.Run("UnionFind Union() 1M edges", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			uf := unionfind.NewUnionFind(n)
+			for _, edge := range edges {
+				uf.Union(edge[0], edge[1])
+			}
+		}
+
+		bytesPerElement(b, func() any {
+			uf := unionfind.NewUnionFind(n)
+			uf.UnionAll(edges, 1)
+			return uf
+		})
+	})
+
+	b.Run("CompactUnionFind Union() 1M edges", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			uf := unionfind.NewCompactUnionFind(n)
+			for _, edge := range edges {
+				uf.Union(edge[0], edge[1])
+			}
+		}
+
+		bytesPerElement(b, func() any {
+			uf := unionfind.NewCompactUnionFind(n)
+			uf.UnionAll(edges, 1)
+			return uf
+		})
+	})
+}
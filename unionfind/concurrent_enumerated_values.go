@@ -0,0 +1,103 @@
+package unionfind
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+const concurrentValueShards = 64
+
+// ConcurrentEnumeratedValues is an EnumeratedValues that is safe for concurrent use.
+// Values are spread over shards by hash, each guarded by its own lock,
+// so goroutines enumerating different values rarely contend.
+type ConcurrentEnumeratedValues[T comparable] struct {
+	seed   maphash.Seed
+	shards [concurrentValueShards]valueShard[T]
+	// mu guards indexedElements
+	mu              sync.RWMutex
+	indexedElements []T
+}
+
+type valueShard[T comparable] struct {
+	mu             sync.RWMutex
+	elementIndices map[T]int
+}
+
+// NewConcurrentEnumeratedValues creates a new ConcurrentEnumeratedValues with the specified size
+func NewConcurrentEnumeratedValues[T comparable](size int) *ConcurrentEnumeratedValues[T] {
+	ev := &ConcurrentEnumeratedValues[T]{
+		seed:            maphash.MakeSeed(),
+		indexedElements: make([]T, 0, size),
+	}
+	for i := range ev.shards {
+		ev.shards[i].elementIndices = make(map[T]int, size/concurrentValueShards)
+	}
+
+	return ev
+}
+
+func (ev *ConcurrentEnumeratedValues[T]) shard(element T) *valueShard[T] {
+	return &ev.shards[maphash.Comparable(ev.seed, element)%concurrentValueShards]
+}
+
+// FetchIndex gets or creates an index for the given element
+func (ev *ConcurrentEnumeratedValues[T]) FetchIndex(element T) int {
+	shard := ev.shard(element)
+
+	shard.mu.RLock()
+	idx, ok := shard.elementIndices[element]
+	shard.mu.RUnlock()
+	if ok {
+		return idx
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// Another goroutine may have enumerated the element since the read lock was released
+	if idx, ok := shard.elementIndices[element]; ok {
+		return idx
+	}
+
+	ev.mu.Lock()
+	idx = len(ev.indexedElements)
+	ev.indexedElements = append(ev.indexedElements, element)
+	ev.mu.Unlock()
+
+	shard.elementIndices[element] = idx
+	return idx
+}
+
+// Lookup returns the index of the given element without creating one
+// if the element has not been enumerated yet
+func (ev *ConcurrentEnumeratedValues[T]) Lookup(element T) (int, bool) {
+	shard := ev.shard(element)
+
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	idx, ok := shard.elementIndices[element]
+	return idx, ok
+}
+
+// Contains reports whether the given element has been enumerated
+func (ev *ConcurrentEnumeratedValues[T]) Contains(element T) bool {
+	_, ok := ev.Lookup(element)
+	return ok
+}
+
+// At returns the element at the given index
+func (ev *ConcurrentEnumeratedValues[T]) At(index int) T {
+	ev.mu.RLock()
+	defer ev.mu.RUnlock()
+
+	return ev.indexedElements[index]
+}
+
+// Len returns the number of enumerated elements
+func (ev *ConcurrentEnumeratedValues[T]) Len() int {
+	ev.mu.RLock()
+	defer ev.mu.RUnlock()
+
+	return len(ev.indexedElements)
+}
@@ -0,0 +1,247 @@
+package unionfind
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentUnionFind is a union-find structure that is safe for concurrent use.
+// Find, Union and Connected never block each other, they update the parent array
+// with compare-and-swap and use path halving in place of path compression.
+// The parent array grows lazily like UnionFind, but growing blocks every other
+// operation so it's cheapest to size the structure up front.
+//
+// Instead of union by rank, roots are linked by a fixed pseudo-random priority
+// derived from their index which keeps trees shallow in expectation
+// without having to update a rank atomically alongside the parent,
+// as described in "Concurrent Disjoint Set Union" by Jayanti and Tarjan.
+type ConcurrentUnionFind struct {
+	// mu is held shared by every operation and exclusively while growing parent
+	mu sync.RWMutex
+	// Parent of each element by index, -1 for elements that haven't been added
+	parent    []int64
+	rootCount atomic.Int64
+}
+
+// NewConcurrentUnionFind creates a new ConcurrentUnionFind with the specified capacity
+func NewConcurrentUnionFind(capacity int) *ConcurrentUnionFind {
+	uf := &ConcurrentUnionFind{}
+	uf.parent = growParents(nil, capacity)
+	return uf
+}
+
+func growParents(parent []int64, n int) []int64 {
+	grown := make([]int64, n)
+	copy(grown, parent)
+	for i := len(parent); i < n; i++ {
+		grown[i] = -1
+	}
+
+	return grown
+}
+
+// rlockFor takes the shared lock, first growing the parent array if it can't hold index n
+func (uf *ConcurrentUnionFind) rlockFor(n int) {
+	uf.mu.RLock()
+	if n < len(uf.parent) {
+		return
+	}
+	uf.mu.RUnlock()
+
+	uf.mu.Lock()
+	if n >= len(uf.parent) {
+		uf.parent = growParents(uf.parent, max(n+1, 2*len(uf.parent)))
+	}
+	uf.mu.Unlock()
+
+	uf.mu.RLock()
+}
+
+// contains must be called while holding mu
+func (uf *ConcurrentUnionFind) contains(n int) bool {
+	return n >= 0 && n < len(uf.parent) && atomic.LoadInt64(&uf.parent[n]) >= 0
+}
+
+// addElement must be called while holding mu
+func (uf *ConcurrentUnionFind) addElement(n int) {
+	if atomic.LoadInt64(&uf.parent[n]) < 0 && atomic.CompareAndSwapInt64(&uf.parent[n], -1, int64(n)) {
+		uf.rootCount.Add(1)
+	}
+}
+
+// find must be called while holding mu, with index already added
+func (uf *ConcurrentUnionFind) find(index int) int {
+	for {
+		parent := int(atomic.LoadInt64(&uf.parent[index]))
+		if parent == index {
+			return index
+		}
+
+		grandparent := atomic.LoadInt64(&uf.parent[parent])
+		if int(grandparent) != parent {
+			// Path halving, losing the race is fine as another goroutine moved index closer to its root
+			atomic.CompareAndSwapInt64(&uf.parent[index], int64(parent), grandparent)
+		}
+		index = int(grandparent)
+	}
+}
+
+// linkPriority is a splitmix64 hash of the index, used to decide which root becomes the parent
+func linkPriority(index int) uint64 {
+	z := uint64(index) + 0x9e3779b97f4a7c15 //nolint:gosec // wrapping is intended
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func linksAbove(a, b int) bool {
+	pa, pb := linkPriority(a), linkPriority(b)
+	return pa > pb || (pa == pb && a < b)
+}
+
+// Find returns the root of the set containing the given index.
+// The element is added if it hasn't been already.
+func (uf *ConcurrentUnionFind) Find(index int) int {
+	uf.rlockFor(index)
+	defer uf.mu.RUnlock()
+
+	uf.addElement(index)
+	return uf.find(index)
+}
+
+// Union merges the sets containing a and b, returning the root of the merged set.
+// Concurrent unions may link the returned root under another root before Union returns.
+func (uf *ConcurrentUnionFind) Union(a, b int) int {
+	uf.rlockFor(max(a, b))
+	defer uf.mu.RUnlock()
+
+	return uf.union(a, b)
+}
+
+// union must be called while holding mu, with a and b in range of parent
+func (uf *ConcurrentUnionFind) union(a, b int) int {
+	uf.addElement(a)
+	uf.addElement(b)
+
+	for {
+		rootA, rootB := uf.find(a), uf.find(b)
+		if rootA == rootB {
+			return rootA
+		}
+
+		if !linksAbove(rootA, rootB) {
+			rootA, rootB = rootB, rootA
+		}
+
+		// Fails if rootB stopped being a root since it was found, in which case retry
+		if atomic.CompareAndSwapInt64(&uf.parent[rootB], int64(rootB), int64(rootA)) {
+			return rootA
+		}
+	}
+}
+
+// Connected reports whether a and b are in the same set.
+// Elements that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *ConcurrentUnionFind) Connected(a, b int) bool {
+	uf.mu.RLock()
+	defer uf.mu.RUnlock()
+
+	if !uf.contains(a) || !uf.contains(b) {
+		return false
+	}
+
+	for {
+		rootA, rootB := uf.find(a), uf.find(b)
+		if rootA == rootB {
+			return true
+		}
+
+		// If rootA is still a root, a and b were in different sets when rootB was found
+		if int(atomic.LoadInt64(&uf.parent[rootA])) == rootA {
+			return false
+		}
+	}
+}
+
+// Contains reports whether the element at the given index has been added
+func (uf *ConcurrentUnionFind) Contains(index int) bool {
+	uf.mu.RLock()
+	defer uf.mu.RUnlock()
+
+	return uf.contains(index)
+}
+
+// RootCount returns the number of elements that have been added
+func (uf *ConcurrentUnionFind) RootCount() int {
+	return int(uf.rootCount.Load())
+}
+
+// Snapshot copies the current state into a new UnionFind,
+// blocking all other operations while it copies
+func (uf *ConcurrentUnionFind) Snapshot() *UnionFind {
+	uf.mu.Lock()
+	defer uf.mu.Unlock()
+
+	snapshot := NewUnionFind(len(uf.parent))
+	uf.copyTo(snapshot)
+	return snapshot
+}
+
+// newConcurrentUnionFindFrom creates a ConcurrentUnionFind holding the same sets as uf
+func newConcurrentUnionFindFrom(uf *UnionFind, capacity int) *ConcurrentUnionFind {
+	concurrent := NewConcurrentUnionFind(max(capacity, len(uf.Root)))
+	for i, initialized := range uf.Initialized {
+		if initialized {
+			concurrent.parent[i] = int64(uf.findRoot(i))
+		}
+	}
+	concurrent.rootCount.Store(int64(uf.RootCount))
+
+	return concurrent
+}
+
+// copyTo replaces the sets in dst with flattened copies of the sets in uf.
+// uf must not be modified while it copies.
+func (uf *ConcurrentUnionFind) copyTo(dst *UnionFind) {
+	if len(uf.parent) > 0 {
+		dst.grow(len(uf.parent) - 1)
+	}
+
+	// Elements that are new to dst are taken to be added in index order
+	added := dst.RootCount
+	dst.RootCount = 0
+	for i := range uf.parent {
+		if !uf.contains(i) {
+			continue
+		}
+
+		if !dst.Initialized[i] && dst.addedAt != nil {
+			dst.addedAt[i] = added
+			added++
+		}
+
+		root := uf.find(i)
+		dst.Root[i] = root
+		dst.Initialized[i] = true
+		dst.RootCount++
+		if root == i {
+			dst.Rank[i] = 0
+		}
+	}
+
+	for i := range uf.parent {
+		if dst.Initialized[i] {
+			dst.Rank[dst.Root[i]]++
+		}
+	}
+
+	dst.NonSingletonCount = 0
+	for i := range uf.parent {
+		if dst.Initialized[i] && dst.Root[i] == i && dst.Rank[i] > 1 {
+			dst.NonSingletonCount++
+		}
+	}
+
+	dst.electRepresentatives()
+}
@@ -0,0 +1,103 @@
+package unionfind_test
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertSamePartition checks that every element has the same set mates in both structures
+func assertSamePartition(t *testing.T, n int, expected, actual func(int) int) {
+	t.Helper()
+
+	expectedToActual := make(map[int]int)
+	actualToExpected := make(map[int]int)
+	for i := 0; i < n; i++ {
+		e, a := expected(i), actual(i)
+		if root, ok := expectedToActual[e]; ok {
+			require.Equal(t, root, a, "element %d should be in the same set as its expected set mates", i)
+		}
+		if root, ok := actualToExpected[a]; ok {
+			require.Equal(t, root, e, "element %d should not share a set with elements from another expected set", i)
+		}
+		expectedToActual[e] = a
+		actualToExpected[a] = e
+	}
+}
+
+func TestConcurrentUnionFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Union/Find/Connected", func(t *testing.T) {
+		uf := unionfind.NewConcurrentUnionFind(0)
+		uf.Union(1, 2)
+		uf.Union(2, 3)
+		uf.Union(5, 6)
+
+		assert.Equal(t, uf.Find(1), uf.Find(3))
+		assert.True(t, uf.Connected(1, 3))
+		assert.False(t, uf.Connected(1, 5))
+		assert.False(t, uf.Connected(1, 100))
+		assert.False(t, uf.Contains(100), "Connected should not add elements")
+		assert.Equal(t, 5, uf.RootCount())
+	})
+
+	t.Run("concurrent unions match sequential unions", func(t *testing.T) {
+		const n, workers = 20000, 8
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		edges := make([][2]int, n)
+		for i := range edges {
+			edges[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+		}
+
+		expected := unionfind.NewUnionFind(n)
+		for _, edge := range edges {
+			expected.Union(edge[0], edge[1])
+		}
+
+		uf := unionfind.NewConcurrentUnionFind(0)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := w; i < len(edges); i += workers {
+					uf.Union(edges[i][0], edges[i][1])
+					uf.Connected(edges[i][1], edges[(i+1)%len(edges)][0])
+					uf.Find(edges[i][0])
+				}
+			}()
+		}
+		wg.Wait()
+
+		for _, edge := range edges {
+			require.True(t, uf.Connected(edge[0], edge[1]))
+		}
+		assertSamePartition(t, n, expected.Find, uf.Find)
+
+		snapshot := uf.Snapshot()
+		assert.Equal(t, uf.RootCount(), snapshot.RootCount)
+		assertSamePartition(t, n, expected.Find, snapshot.Find)
+		for i := 0; i < n; i++ {
+			require.Equal(t, expected.Size(i), snapshot.Size(i))
+		}
+		assert.Equal(t, expected.NonSingletonCount, snapshot.NonSingletonCount)
+	})
+}
+
+func BenchmarkConcurrentUnionFind(b *testing.B) {
+	b.Run("Union() in parallel", func(b *testing.B) {
+		uf := unionfind.NewConcurrentUnionFind(100000)
+		b.RunParallel(func(pb *testing.PB) {
+			rng := rand.New(rand.NewSource(rand.Int63())) //nolint:gosec // test code using weak random is acceptable
+			for pb.Next() {
+				uf.Union(rng.Intn(100000), rng.Intn(100000))
+			}
+		})
+	})
+}
@@ -0,0 +1,54 @@
+package unionfind
+
+// ConcurrentUnionFindWithValues is an AlgoUnionFindWithValues that is safe for concurrent use
+type ConcurrentUnionFindWithValues[T comparable] struct {
+	*ConcurrentUnionFind
+	values *ConcurrentEnumeratedValues[T]
+}
+
+// NewConcurrentUnionFindWithValues creates a new ConcurrentUnionFindWithValues with the specified capacity
+func NewConcurrentUnionFindWithValues[T comparable](capacity int) *ConcurrentUnionFindWithValues[T] {
+	return &ConcurrentUnionFindWithValues[T]{
+		ConcurrentUnionFind: NewConcurrentUnionFind(capacity),
+		values:              NewConcurrentEnumeratedValues[T](capacity),
+	}
+}
+
+// Find returns the root index of the set containing the given value
+func (uf *ConcurrentUnionFindWithValues[T]) Find(value T) int {
+	return uf.ConcurrentUnionFind.Find(uf.values.FetchIndex(value))
+}
+
+// FindReturningValue returns the root value of the set containing the given value
+func (uf *ConcurrentUnionFindWithValues[T]) FindReturningValue(value T) T {
+	return uf.values.At(uf.Find(value))
+}
+
+// Connected reports whether values a and b are in the same set.
+// Values that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *ConcurrentUnionFindWithValues[T]) Connected(a, b T) bool {
+	indexA, okA := uf.values.Lookup(a)
+	indexB, okB := uf.values.Lookup(b)
+	if !okA || !okB {
+		return false
+	}
+
+	return uf.ConcurrentUnionFind.Connected(indexA, indexB)
+}
+
+// Union merges the sets containing values a and b, returning the root index
+func (uf *ConcurrentUnionFindWithValues[T]) Union(a, b T) int {
+	indexA := uf.values.FetchIndex(a)
+	indexB := uf.values.FetchIndex(b)
+
+	return uf.ConcurrentUnionFind.Union(indexA, indexB)
+}
+
+// UnionReturningValue merges the sets containing values a and b, returning the root value.
+// This is handy for directly getting new root value, but is slower
+// because it looks up value by index.
+func (uf *ConcurrentUnionFindWithValues[T]) UnionReturningValue(a, b T) T {
+	idx := uf.Union(a, b)
+	return uf.values.At(idx)
+}
@@ -0,0 +1,91 @@
+package unionfind_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentUnionFindWithValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Union/Find", func(t *testing.T) {
+		uf := unionfind.NewConcurrentUnionFindWithValues[string](0)
+		root := uf.UnionReturningValue("A", "B")
+		assert.Equal(t, root, uf.FindReturningValue("A"))
+		assert.Equal(t, root, uf.FindReturningValue("B"))
+		assert.True(t, uf.Connected("A", "B"))
+		assert.False(t, uf.Connected("A", "C"))
+	})
+
+	t.Run("concurrent unions of values", func(t *testing.T) {
+		const groups, groupSize, workers = 100, 50, 8
+		uf := unionfind.NewConcurrentUnionFindWithValues[string](0)
+
+		// Every worker links each group member to the next, in an interleaved order
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for g := 0; g < groups; g++ {
+					for m := w; m < groupSize-1; m += workers {
+						uf.Union(member(g, m), member(g, m+1))
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		for g := 0; g < groups; g++ {
+			root := uf.FindReturningValue(member(g, 0))
+			for m := 1; m < groupSize; m++ {
+				require.Equal(t, root, uf.FindReturningValue(member(g, m)))
+			}
+			require.False(t, uf.Connected(member(g, 0), member((g+1)%groups, 0)))
+		}
+		assert.Equal(t, groups*groupSize, uf.RootCount())
+	})
+}
+
+func member(group, m int) string {
+	return strconv.Itoa(group) + "/" + strconv.Itoa(m)
+}
+
+func TestConcurrentEnumeratedValues(t *testing.T) {
+	t.Parallel()
+
+	const values, workers = 1000, 8
+	ev := unionfind.NewConcurrentEnumeratedValues[int](0)
+
+	indices := make([][]int, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			indices[w] = make([]int, values)
+			for v := 0; v < values; v++ {
+				indices[w][v] = ev.FetchIndex(v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, values, ev.Len())
+	for v := 0; v < values; v++ {
+		for w := 1; w < workers; w++ {
+			require.Equal(t, indices[0][v], indices[w][v], "every worker should get the same index for a value")
+		}
+		require.Equal(t, v, ev.At(indices[0][v]))
+		idx, ok := ev.Lookup(v)
+		require.True(t, ok)
+		require.Equal(t, indices[0][v], idx)
+	}
+	assert.False(t, ev.Contains(values))
+}
@@ -0,0 +1,422 @@
+package unionfind
+
+import "iter"
+
+// ConstrainedUnionFind is a union-find structure that refuses to merge sets
+// when a cannot-link constraint would end up inside the merged set,
+// such as two different verified customers being joined by a chain of weaker matches.
+// Constraints are held against the whole set, so they are checked
+// however indirectly the two sides would be connected.
+// The underlying UnionFind isn't exposed, since merging, removals, rollback and
+// parallel unions on it would bypass the constraints, so only the queries
+// and settings that can't break a constraint are available.
+type ConstrainedUnionFind struct {
+	sets *UnionFind
+	// Elements each set cannot be merged with by tree root index.
+	// Both sides of a constraint are recorded against their own set
+	// so it's enough to check the smaller side when merging.
+	cannotLink map[int]map[int]struct{}
+	// Number of unions that merged two sets, so RootCount can subtract them
+	// from the number of elements added
+	merges int
+}
+
+// NewConstrainedUnionFind creates a new ConstrainedUnionFind with the specified capacity
+func NewConstrainedUnionFind(capacity int) *ConstrainedUnionFind {
+	return newConstrainedUnionFindFrom(NewUnionFind(capacity))
+}
+
+func newConstrainedUnionFindFrom(uf *UnionFind) *ConstrainedUnionFind {
+	return &ConstrainedUnionFind{
+		sets:       uf,
+		cannotLink: make(map[int]map[int]struct{}),
+	}
+}
+
+// CannotLink records that a and b must never be in the same set.
+// It returns false without recording anything if they already are.
+func (uf *ConstrainedUnionFind) CannotLink(a, b int) bool {
+	rootA := uf.sets.findRoot(a)
+	rootB := uf.sets.findRoot(b)
+	if rootA == rootB {
+		return false
+	}
+
+	uf.forbid(rootA, b)
+	uf.forbid(rootB, a)
+
+	return true
+}
+
+func (uf *ConstrainedUnionFind) forbid(root, element int) {
+	if uf.cannotLink[root] == nil {
+		uf.cannotLink[root] = make(map[int]struct{})
+	}
+	uf.cannotLink[root][element] = struct{}{}
+}
+
+// Linkable reports whether the sets containing a and b could be merged
+// without breaking a constraint. Elements that have not been added
+// are linkable to anything and are not added by the check.
+func (uf *ConstrainedUnionFind) Linkable(a, b int) bool {
+	if !uf.sets.Contains(a) || !uf.sets.Contains(b) {
+		return true
+	}
+
+	return !uf.conflicts(uf.sets.findRoot(a), uf.sets.findRoot(b))
+}
+
+// conflicts reports whether a constraint forbids merging the trees rooted at rootA and rootB
+func (uf *ConstrainedUnionFind) conflicts(rootA, rootB int) bool {
+	if rootA == rootB {
+		return false
+	}
+
+	forbidden, other := uf.cannotLink[rootA], rootB
+	if len(uf.cannotLink[rootB]) < len(forbidden) {
+		forbidden, other = uf.cannotLink[rootB], rootA
+	}
+
+	for element := range forbidden {
+		if uf.sets.findRoot(element) == other {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Union merges the sets containing a and b, returning the root of the merged set.
+// It returns the root of a's set and false without merging anything
+// if a constraint forbids the two sets from being merged,
+// or if the merged set would be larger than the maximum set size.
+func (uf *ConstrainedUnionFind) Union(a, b int) (int, bool) {
+	rootA := uf.sets.findRoot(a)
+	rootB := uf.sets.findRoot(b)
+
+	if uf.conflicts(rootA, rootB) {
+		return uf.sets.representativeOf(rootA), false
+	}
+
+	root, ok := uf.sets.union(a, b)
+	if !ok {
+		uf.sets.reject(a, b)
+		return root, false
+	}
+	if rootA != rootB {
+		uf.merges++
+		if uf.sets.provenance != nil {
+			uf.sets.provenance.record([2]int{a, b}, a, b)
+		}
+		uf.mergeConstraints(rootA, rootB, uf.sets.findRoot(a))
+	}
+
+	return root, true
+}
+
+// mergeConstraints moves the constraints of the sets formerly rooted at rootA and rootB
+// onto the merged set rooted at root, copying the smaller into the larger
+func (uf *ConstrainedUnionFind) mergeConstraints(rootA, rootB, root int) {
+	larger, smaller := uf.cannotLink[rootA], uf.cannotLink[rootB]
+	if len(larger) < len(smaller) {
+		larger, smaller = smaller, larger
+	}
+
+	delete(uf.cannotLink, rootA)
+	delete(uf.cannotLink, rootB)
+	if len(larger) == 0 {
+		return
+	}
+
+	for element := range smaller {
+		larger[element] = struct{}{}
+	}
+	uf.cannotLink[root] = larger
+}
+
+// UnionAll unions both ends of every edge in order,
+// returning the edges that were refused because of a constraint
+func (uf *ConstrainedUnionFind) UnionAll(edges [][2]int) [][2]int {
+	var refused [][2]int
+	for _, edge := range edges {
+		if _, ok := uf.Union(edge[0], edge[1]); !ok {
+			refused = append(refused, edge)
+		}
+	}
+
+	return refused
+}
+
+// Find returns the root of the set containing the given index
+func (uf *ConstrainedUnionFind) Find(index int) int {
+	return uf.sets.Find(index)
+}
+
+// Contains reports whether the element at the given index has been added
+func (uf *ConstrainedUnionFind) Contains(index int) bool {
+	return uf.sets.Contains(index)
+}
+
+// TryFind returns the root of the set containing the given index
+// and false if the element has not been added. The structure is left unchanged.
+func (uf *ConstrainedUnionFind) TryFind(index int) (int, bool) {
+	return uf.sets.TryFind(index)
+}
+
+// Connected reports whether a and b are in the same set.
+// Elements that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *ConstrainedUnionFind) Connected(a, b int) bool {
+	return uf.sets.Connected(a, b)
+}
+
+// Size returns the exact number of elements in the set containing the given index,
+// 0 if the element has not been added
+func (uf *ConstrainedUnionFind) Size(index int) int {
+	return uf.sets.Size(index)
+}
+
+// Members returns the indices of all elements in the set containing index,
+// nil if the element has not been added
+func (uf *ConstrainedUnionFind) Members(index int) []int {
+	return uf.sets.Members(index)
+}
+
+// Roots returns an iterator over the root index of every set
+func (uf *ConstrainedUnionFind) Roots() iter.Seq[int] {
+	return uf.sets.Roots()
+}
+
+// Sets returns an iterator over every set, yielding its root index
+// along with the indices of all of its members
+func (uf *ConstrainedUnionFind) Sets() iter.Seq2[int, []int] {
+	return uf.sets.Sets()
+}
+
+// LargestSets returns the root indices of the k largest sets, largest first
+func (uf *ConstrainedUnionFind) LargestSets(k int) []int {
+	return uf.sets.LargestSets(k)
+}
+
+// RootCount returns the number of sets
+func (uf *ConstrainedUnionFind) RootCount() int {
+	return uf.sets.RootCount - uf.merges
+}
+
+// NonSingletonCount returns the number of sets with more than one member
+func (uf *ConstrainedUnionFind) NonSingletonCount() int {
+	return uf.sets.NonSingletonCount
+}
+
+// SetMaxSetSize limits the size of the sets unions may create, see UnionFind.SetMaxSetSize
+func (uf *ConstrainedUnionFind) SetMaxSetSize(size int) {
+	uf.sets.SetMaxSetSize(size)
+}
+
+// MaxSetSize returns the largest set a union may create, 0 if there's no limit
+func (uf *ConstrainedUnionFind) MaxSetSize() int {
+	return uf.sets.MaxSetSize()
+}
+
+// RejectedEdges returns the edges whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (uf *ConstrainedUnionFind) RejectedEdges() [][2]int {
+	return uf.sets.RejectedEdges()
+}
+
+// SetRootPolicy sets which member of each set is reported as its root
+func (uf *ConstrainedUnionFind) SetRootPolicy(policy RootPolicy) {
+	uf.sets.SetRootPolicy(policy)
+}
+
+// EnableProvenance starts recording the unions that merge sets, see UnionFind.EnableProvenance
+func (uf *ConstrainedUnionFind) EnableProvenance() {
+	uf.sets.EnableProvenance()
+}
+
+// DisableProvenance stops recording unions and forgets the ones recorded
+func (uf *ConstrainedUnionFind) DisableProvenance() {
+	uf.sets.DisableProvenance()
+}
+
+// Explain returns the chain of edges passed to Union that connects a to b,
+// and false if they aren't connected or provenance wasn't enabled when they were
+func (uf *ConstrainedUnionFind) Explain(a, b int) ([][2]int, bool) {
+	return uf.sets.Explain(a, b)
+}
+
+// ConstrainedUnionFindWithValues represents a constrained union-find structure with generic values.
+// Like ConstrainedUnionFind it only exposes the operations that can't break a constraint.
+type ConstrainedUnionFindWithValues[T comparable] struct {
+	sets        *AlgoUnionFindWithValues[T]
+	constraints *ConstrainedUnionFind
+}
+
+// NewConstrainedUnionFindWithValues creates a new ConstrainedUnionFindWithValues with the specified capacity
+func NewConstrainedUnionFindWithValues[T comparable](capacity int) *ConstrainedUnionFindWithValues[T] {
+	uf := NewUnionFindWithValues[T](capacity)
+	return &ConstrainedUnionFindWithValues[T]{
+		sets:        uf,
+		constraints: newConstrainedUnionFindFrom(uf.UnionFind),
+	}
+}
+
+// CannotLink records that values a and b must never be in the same set.
+// It returns false without recording anything if they already are.
+func (uf *ConstrainedUnionFindWithValues[T]) CannotLink(a, b T) bool {
+	return uf.constraints.CannotLink(uf.sets.values.FetchIndex(a), uf.sets.values.FetchIndex(b))
+}
+
+// Linkable reports whether the sets containing values a and b could be merged
+// without breaking a constraint. Values that have not been added
+// are linkable to anything and are not added by the check.
+func (uf *ConstrainedUnionFindWithValues[T]) Linkable(a, b T) bool {
+	indexA, okA := uf.sets.values.Lookup(a)
+	indexB, okB := uf.sets.values.Lookup(b)
+	if !okA || !okB {
+		return true
+	}
+
+	return uf.constraints.Linkable(indexA, indexB)
+}
+
+// Union merges the sets containing values a and b, returning the root index of the merged set.
+// It returns the root index of a's set and false without merging anything
+// if a constraint forbids the two sets from being merged.
+func (uf *ConstrainedUnionFindWithValues[T]) Union(a, b T) (int, bool) {
+	return uf.constraints.Union(uf.sets.values.FetchIndex(a), uf.sets.values.FetchIndex(b))
+}
+
+// UnionReturningValue is like Union but returns the root value
+func (uf *ConstrainedUnionFindWithValues[T]) UnionReturningValue(a, b T) (T, bool) {
+	root, ok := uf.Union(a, b)
+	return uf.sets.values.At(root), ok
+}
+
+// UnionAll unions every pair of values in order,
+// returning the pairs that were refused because of a constraint
+func (uf *ConstrainedUnionFindWithValues[T]) UnionAll(pairs [][2]T) [][2]T {
+	var refused [][2]T
+	for _, pair := range pairs {
+		if _, ok := uf.Union(pair[0], pair[1]); !ok {
+			refused = append(refused, pair)
+		}
+	}
+
+	return refused
+}
+
+// Find returns the root index of the set containing the given value
+func (uf *ConstrainedUnionFindWithValues[T]) Find(value T) int {
+	return uf.sets.Find(value)
+}
+
+// FindReturningValue returns the root value of the set containing the given value
+func (uf *ConstrainedUnionFindWithValues[T]) FindReturningValue(value T) T {
+	return uf.sets.FindReturningValue(value)
+}
+
+// Contains reports whether the given value has been added
+func (uf *ConstrainedUnionFindWithValues[T]) Contains(value T) bool {
+	return uf.sets.Contains(value)
+}
+
+// TryFind returns the root index of the set containing the given value
+// and false if the value has not been added. The structure is left unchanged.
+func (uf *ConstrainedUnionFindWithValues[T]) TryFind(value T) (int, bool) {
+	return uf.sets.TryFind(value)
+}
+
+// TryFindReturningValue returns the root value of the set containing the given value
+// and false if the value has not been added. The structure is left unchanged.
+func (uf *ConstrainedUnionFindWithValues[T]) TryFindReturningValue(value T) (T, bool) {
+	return uf.sets.TryFindReturningValue(value)
+}
+
+// Connected reports whether values a and b are in the same set.
+// Values that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *ConstrainedUnionFindWithValues[T]) Connected(a, b T) bool {
+	return uf.sets.Connected(a, b)
+}
+
+// SizeOf returns the exact number of values in the set containing the given value,
+// 0 if the value has not been added
+func (uf *ConstrainedUnionFindWithValues[T]) SizeOf(value T) int {
+	return uf.sets.SizeOf(value)
+}
+
+// Members returns all values in the set containing the given value,
+// nil if the value has not been added
+func (uf *ConstrainedUnionFindWithValues[T]) Members(value T) []T {
+	return uf.sets.Members(value)
+}
+
+// Roots returns an iterator over the root value of every set
+func (uf *ConstrainedUnionFindWithValues[T]) Roots() iter.Seq[T] {
+	return uf.sets.Roots()
+}
+
+// Sets returns an iterator over every set, yielding its root value
+// along with the values of all of its members
+func (uf *ConstrainedUnionFindWithValues[T]) Sets() iter.Seq2[T, []T] {
+	return uf.sets.Sets()
+}
+
+// LargestSets returns the root values of the k largest sets, largest first
+func (uf *ConstrainedUnionFindWithValues[T]) LargestSets(k int) []T {
+	return uf.sets.LargestSets(k)
+}
+
+// RootCount returns the number of sets
+func (uf *ConstrainedUnionFindWithValues[T]) RootCount() int {
+	return uf.constraints.RootCount()
+}
+
+// NonSingletonCount returns the number of sets with more than one member
+func (uf *ConstrainedUnionFindWithValues[T]) NonSingletonCount() int {
+	return uf.sets.NonSingletonCount
+}
+
+// SetMaxSetSize limits the size of the sets unions may create, see UnionFind.SetMaxSetSize
+func (uf *ConstrainedUnionFindWithValues[T]) SetMaxSetSize(size int) {
+	uf.sets.SetMaxSetSize(size)
+}
+
+// MaxSetSize returns the largest set a union may create, 0 if there's no limit
+func (uf *ConstrainedUnionFindWithValues[T]) MaxSetSize() int {
+	return uf.sets.MaxSetSize()
+}
+
+// RejectedEdges returns the pairs of values whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (uf *ConstrainedUnionFindWithValues[T]) RejectedEdges() [][2]T {
+	return uf.sets.RejectedEdges()
+}
+
+// SetRootPolicy sets which member of each set is reported as its root
+func (uf *ConstrainedUnionFindWithValues[T]) SetRootPolicy(policy RootPolicy) {
+	uf.sets.SetRootPolicy(policy)
+}
+
+// SetRootCmp reports the smallest member of each set by cmp as its root,
+// see AlgoUnionFindWithValues.SetRootCmp
+func (uf *ConstrainedUnionFindWithValues[T]) SetRootCmp(cmp func(a, b T) int) {
+	uf.sets.SetRootCmp(cmp)
+}
+
+// EnableProvenance starts recording the unions that merge sets, see UnionFind.EnableProvenance
+func (uf *ConstrainedUnionFindWithValues[T]) EnableProvenance() {
+	uf.sets.EnableProvenance()
+}
+
+// DisableProvenance stops recording unions and forgets the ones recorded
+func (uf *ConstrainedUnionFindWithValues[T]) DisableProvenance() {
+	uf.sets.DisableProvenance()
+}
+
+// Explain returns the chain of pairs passed to Union that connects values a and b,
+// and false if they aren't connected or provenance wasn't enabled when they were
+func (uf *ConstrainedUnionFindWithValues[T]) Explain(a, b T) ([][2]T, bool) {
+	return uf.sets.Explain(a, b)
+}
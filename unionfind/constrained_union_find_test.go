@@ -0,0 +1,127 @@
+package unionfind_test
+
+import (
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstrainedUnionFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("constraints hold across transitive chains", func(t *testing.T) {
+		uf := unionfind.NewConstrainedUnionFind(0)
+		assert.True(t, uf.CannotLink(0, 5))
+
+		_, ok := uf.Union(0, 1)
+		assert.True(t, ok)
+		_, ok = uf.Union(5, 4)
+		assert.True(t, ok)
+		_, ok = uf.Union(2, 3)
+		assert.True(t, ok)
+		_, ok = uf.Union(1, 2)
+		assert.True(t, ok)
+
+		assert.False(t, uf.Linkable(3, 4))
+		root, ok := uf.Union(3, 4)
+		assert.False(t, ok)
+		assert.Equal(t, uf.Find(3), root)
+		assert.False(t, uf.Connected(0, 5))
+
+		// Sets merged after the constraint was recorded carry it too
+		_, ok = uf.Union(6, 4)
+		assert.True(t, ok)
+		_, ok = uf.Union(7, 6)
+		assert.True(t, ok)
+		_, ok = uf.Union(7, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("CannotLink refuses elements already in the same set", func(t *testing.T) {
+		uf := unionfind.NewConstrainedUnionFind(0)
+		uf.Union(0, 1)
+		uf.Union(1, 2)
+
+		assert.False(t, uf.CannotLink(2, 0))
+		_, ok := uf.Union(0, 2)
+		assert.True(t, ok)
+		assert.Equal(t, 1, uf.RootCount())
+	})
+
+	t.Run("Linkable does not add elements", func(t *testing.T) {
+		uf := unionfind.NewConstrainedUnionFind(0)
+		uf.CannotLink(0, 1)
+
+		assert.True(t, uf.Linkable(0, 9))
+		assert.False(t, uf.Contains(9))
+		assert.False(t, uf.Linkable(1, 0))
+	})
+
+	t.Run("UnionAll reports refused edges", func(t *testing.T) {
+		uf := unionfind.NewConstrainedUnionFind(0)
+		uf.CannotLink(0, 3)
+		uf.CannotLink(4, 5)
+
+		refused := uf.UnionAll([][2]int{{0, 1}, {2, 3}, {1, 2}, {4, 0}, {5, 3}, {4, 5}})
+		assert.Equal(t, [][2]int{{1, 2}, {4, 5}}, refused)
+		assert.True(t, uf.Connected(0, 4))
+		assert.True(t, uf.Connected(3, 5))
+		assert.False(t, uf.Connected(0, 3))
+	})
+
+	t.Run("with values", func(t *testing.T) {
+		uf := unionfind.NewConstrainedUnionFindWithValues[string](0)
+		assert.True(t, uf.CannotLink("customer-1", "customer-2"))
+
+		uf.Union("customer-1", "alice@example.com")
+		uf.Union("customer-2", "bob@example.com")
+		uf.Union("alice@example.com", "555-0100")
+
+		root, ok := uf.UnionReturningValue("555-0100", "bob@example.com")
+		assert.False(t, ok)
+		assert.Equal(t, "customer-1", root)
+		assert.False(t, uf.Connected("customer-1", "customer-2"))
+		assert.False(t, uf.Linkable("alice@example.com", "bob@example.com"))
+		assert.True(t, uf.Linkable("alice@example.com", "unknown"))
+		assert.False(t, uf.Contains("unknown"))
+
+		refused := uf.UnionAll([][2]string{{"555-0199", "bob@example.com"}, {"555-0199", "555-0100"}})
+		assert.Equal(t, [][2]string{{"555-0199", "555-0100"}}, refused)
+		assert.ElementsMatch(t, []string{"customer-2", "bob@example.com", "555-0199"}, uf.Members("customer-2"))
+		assert.Equal(t, 2, uf.RootCount())
+	})
+
+	t.Run("maximum set size", func(t *testing.T) {
+		uf := unionfind.NewConstrainedUnionFind(0)
+		uf.SetMaxSetSize(2)
+		uf.CannotLink(0, 9)
+
+		refused := uf.UnionAll([][2]int{{0, 1}, {1, 2}, {2, 9}})
+		assert.Equal(t, [][2]int{{1, 2}}, refused)
+		assert.Equal(t, [][2]int{{1, 2}}, uf.RejectedEdges())
+		assert.True(t, uf.Connected(2, 9))
+		assert.Equal(t, 2, uf.RootCount())
+	})
+
+	t.Run("operations that would bypass the constraints aren't exposed", func(t *testing.T) {
+		var uf any = unionfind.NewConstrainedUnionFind(0)
+		_, ok := uf.(interface{ Merge(*unionfind.UnionFind) })
+		assert.False(t, ok)
+		_, ok = uf.(interface{ RemoveEdge(a, b int) bool })
+		assert.False(t, ok)
+		_, ok = uf.(interface {
+			Rollback(unionfind.Checkpoint) error
+		})
+		assert.False(t, ok)
+
+		var values any = unionfind.NewConstrainedUnionFindWithValues[string](0)
+		_, ok = values.(interface {
+			Merge(*unionfind.AlgoUnionFindWithValues[string])
+		})
+		assert.False(t, ok)
+		_, ok = values.(interface{ Remove(string) bool })
+		assert.False(t, ok)
+	})
+}
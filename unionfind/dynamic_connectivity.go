@@ -0,0 +1,207 @@
+package unionfind
+
+// Offline dynamic connectivity answers queries over a timeline of edges being added
+// and removed. Each edge is alive over a contiguous range of queries, which is split
+// across the O(log q) nodes of a segment tree over the queries that cover it.
+// A depth first walk of the tree unions the edges of each node on the way down
+// and rolls them back on the way up, so every query is answered at its leaf
+// with exactly the edges alive at that point, in O((n + q) log q log n) overall.
+
+// EventKind is the kind of an event in a connectivity timeline
+type EventKind int
+
+const (
+	// AddEdgeEvent adds an edge between A and B
+	AddEdgeEvent EventKind = iota
+	// RemoveEdgeEvent removes an edge between A and B added earlier.
+	// Removing an edge that isn't there does nothing.
+	RemoveEdgeEvent
+	// ConnectedEvent asks whether A and B are connected
+	ConnectedEvent
+	// ComponentsEvent asks for the number of components,
+	// A and B are ignored
+	ComponentsEvent
+)
+
+// ConnectivityEvent is an edge being added or removed or a query in a connectivity timeline.
+// Edges are undirected, so an edge from A to B is removed by either order.
+type ConnectivityEvent[T comparable] struct {
+	Kind EventKind
+	A, B T
+}
+
+// ConnectivityAnswer is the answer to a query in a connectivity timeline
+type ConnectivityAnswer struct {
+	// Index of the query in the events
+	Event int
+	// Whether A and B were connected, false for ComponentsEvent
+	Connected bool
+	// Number of components among the elements mentioned by the events up to the query,
+	// counting each element without an edge as a component of its own.
+	// Removing an edge that isn't there doesn't mention its elements.
+	Components int
+}
+
+// SolveConnectivity answers every query in events offline,
+// in the order the queries appear. An edge added more than once
+// is alive until it has been removed as many times.
+func SolveConnectivity[T comparable](events []ConnectivityEvent[T]) []ConnectivityAnswer {
+	values := NewEnumeratedValues[T](len(events))
+	s := newConnectivitySolver(len(events))
+	for i, event := range events {
+		if event.Kind == ComponentsEvent {
+			s.handle(i, event.Kind, -1, -1, values.Len())
+			continue
+		}
+
+		if event.Kind == RemoveEdgeEvent {
+			// An edge between elements that haven't been mentioned can't have been added
+			a, okA := values.Lookup(event.A)
+			b, okB := values.Lookup(event.B)
+			if okA && okB {
+				s.handle(i, event.Kind, a, b, values.Len())
+			}
+			continue
+		}
+
+		a, b := values.FetchIndex(event.A), values.FetchIndex(event.B)
+		s.handle(i, event.Kind, a, b, values.Len())
+	}
+	s.build()
+
+	if len(s.queries) > 0 {
+		s.solve(1, 0, len(s.queries), 0)
+	}
+
+	return s.answers
+}
+
+type connectivityQuery struct {
+	event int
+	kind  EventKind
+	a, b  int
+	// Number of elements mentioned by the events up to the query
+	seen int
+}
+
+type connectivityInterval struct {
+	edge     [2]int
+	from, to int
+}
+
+type connectivitySolver struct {
+	uf      *UnionFind
+	queries []connectivityQuery
+	// Queries each alive edge was added before, one per copy of the edge
+	open map[[2]int][]int
+	// Edges with the range of queries they were alive for
+	intervals []connectivityInterval
+	// Edges alive over the whole range of each node of the segment tree, by node
+	edges   map[int][][2]int
+	answers []ConnectivityAnswer
+}
+
+func newConnectivitySolver(capacity int) *connectivitySolver {
+	uf := NewUnionFind(capacity)
+	uf.EnableRollback()
+
+	return &connectivitySolver{
+		uf:    uf,
+		open:  make(map[[2]int][]int),
+		edges: make(map[int][][2]int),
+	}
+}
+
+// handle records the event at index event, with seen elements mentioned up to it
+func (s *connectivitySolver) handle(event int, kind EventKind, a, b, seen int) {
+	edge := [2]int{min(a, b), max(a, b)}
+
+	switch kind {
+	case AddEdgeEvent:
+		s.open[edge] = append(s.open[edge], len(s.queries))
+	case RemoveEdgeEvent:
+		opened := s.open[edge]
+		if len(opened) == 0 {
+			return
+		}
+
+		s.alive(edge, opened[len(opened)-1], len(s.queries))
+		if len(opened) == 1 {
+			delete(s.open, edge)
+		} else {
+			s.open[edge] = opened[:len(opened)-1]
+		}
+	case ConnectedEvent, ComponentsEvent:
+		s.queries = append(s.queries, connectivityQuery{
+			event: event,
+			kind:  kind,
+			a:     a,
+			b:     b,
+			seen:  seen,
+		})
+	}
+}
+
+// alive records edge as alive for the queries from from up to to
+func (s *connectivitySolver) alive(edge [2]int, from, to int) {
+	if from < to {
+		s.intervals = append(s.intervals, connectivityInterval{edge: edge, from: from, to: to})
+	}
+}
+
+// build fills the segment tree once every query is known,
+// keeping the edges that are never removed alive until the last query
+func (s *connectivitySolver) build() {
+	for edge, opened := range s.open {
+		for _, from := range opened {
+			s.alive(edge, from, len(s.queries))
+		}
+	}
+	s.open = nil
+
+	for _, interval := range s.intervals {
+		s.insert(1, 0, len(s.queries), interval.from, interval.to, interval.edge)
+	}
+	s.intervals = nil
+}
+
+func (s *connectivitySolver) insert(node, lo, hi, from, to int, edge [2]int) {
+	if to <= lo || hi <= from {
+		return
+	}
+	if from <= lo && hi <= to {
+		s.edges[node] = append(s.edges[node], edge)
+		return
+	}
+
+	mid := (lo + hi) / 2
+	s.insert(2*node, lo, mid, from, to, edge)
+	s.insert(2*node+1, mid, hi, from, to, edge)
+}
+
+// solve answers the queries from lo up to hi under node,
+// with merges unions already made by its ancestors
+func (s *connectivitySolver) solve(node, lo, hi, merges int) {
+	checkpoint := s.uf.Checkpoint()
+	for _, edge := range s.edges[node] {
+		if s.uf.findRoot(edge[0]) != s.uf.findRoot(edge[1]) {
+			merges++
+		}
+		s.uf.union(edge[0], edge[1])
+	}
+
+	if hi-lo == 1 {
+		query := s.queries[lo]
+		answer := ConnectivityAnswer{Event: query.event, Components: query.seen - merges}
+		if query.kind == ConnectedEvent {
+			answer.Connected = s.uf.findRoot(query.a) == s.uf.findRoot(query.b)
+		}
+		s.answers = append(s.answers, answer)
+	} else {
+		mid := (lo + hi) / 2
+		s.solve(2*node, lo, mid, merges)
+		s.solve(2*node+1, mid, hi, merges)
+	}
+
+	s.uf.rollbackTo(checkpoint)
+}
@@ -0,0 +1,139 @@
+package unionfind_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolveConnectivity(t *testing.T) {
+	t.Parallel()
+
+	t.Run("answers queries at their point in the timeline", func(t *testing.T) {
+		events := []unionfind.ConnectivityEvent[string]{
+			{Kind: unionfind.AddEdgeEvent, A: "alice", B: "10.0.0.1"},
+			{Kind: unionfind.AddEdgeEvent, A: "bob", B: "10.0.0.1"},
+			{Kind: unionfind.ConnectedEvent, A: "alice", B: "bob"},
+			{Kind: unionfind.ComponentsEvent},
+			{Kind: unionfind.RemoveEdgeEvent, A: "10.0.0.1", B: "bob"},
+			{Kind: unionfind.ConnectedEvent, A: "alice", B: "bob"},
+			{Kind: unionfind.ConnectedEvent, A: "alice", B: "10.0.0.1"},
+			{Kind: unionfind.ConnectedEvent, A: "carol", B: "carol"},
+			{Kind: unionfind.ComponentsEvent},
+		}
+
+		assert.Equal(t, []unionfind.ConnectivityAnswer{
+			{Event: 2, Connected: true, Components: 1},
+			{Event: 3, Components: 1},
+			{Event: 5, Connected: false, Components: 2},
+			{Event: 6, Connected: true, Components: 2},
+			{Event: 7, Connected: true, Components: 3},
+			{Event: 8, Components: 3},
+		}, unionfind.SolveConnectivity(events))
+	})
+
+	t.Run("edges added twice need removing twice", func(t *testing.T) {
+		events := []unionfind.ConnectivityEvent[int]{
+			{Kind: unionfind.AddEdgeEvent, A: 0, B: 1},
+			{Kind: unionfind.AddEdgeEvent, A: 1, B: 0},
+			{Kind: unionfind.RemoveEdgeEvent, A: 0, B: 1},
+			{Kind: unionfind.ConnectedEvent, A: 0, B: 1},
+			{Kind: unionfind.RemoveEdgeEvent, A: 0, B: 1},
+			{Kind: unionfind.RemoveEdgeEvent, A: 0, B: 1}, // not there, ignored
+			{Kind: unionfind.ConnectedEvent, A: 0, B: 1},
+		}
+
+		answers := unionfind.SolveConnectivity(events)
+		require.Len(t, answers, 2)
+		assert.True(t, answers[0].Connected)
+		assert.False(t, answers[1].Connected)
+	})
+
+	t.Run("removing an unknown edge doesn't add its elements", func(t *testing.T) {
+		events := []unionfind.ConnectivityEvent[string]{
+			{Kind: unionfind.AddEdgeEvent, A: "alice", B: "10.0.0.1"},
+			{Kind: unionfind.RemoveEdgeEvent, A: "mallory", B: "10.0.0.2"},
+			{Kind: unionfind.RemoveEdgeEvent, A: "alice", B: "10.0.0.3"},
+			{Kind: unionfind.ComponentsEvent},
+		}
+
+		assert.Equal(t, []unionfind.ConnectivityAnswer{
+			{Event: 3, Components: 1},
+		}, unionfind.SolveConnectivity(events))
+	})
+
+	t.Run("no queries", func(t *testing.T) {
+		assert.Empty(t, unionfind.SolveConnectivity([]unionfind.ConnectivityEvent[int]{
+			{Kind: unionfind.AddEdgeEvent, A: 0, B: 1},
+		}))
+	})
+
+	t.Run("matches rebuilding at every query", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 30
+
+		var events []unionfind.ConnectivityEvent[int]
+		var alive [][2]int
+		for range 1000 {
+			switch r := rng.Intn(10); {
+			case r < 4:
+				edge := [2]int{rng.Intn(n), rng.Intn(n)}
+				alive = append(alive, edge)
+				events = append(events, unionfind.ConnectivityEvent[int]{Kind: unionfind.AddEdgeEvent, A: edge[0], B: edge[1]})
+			case r < 7 && len(alive) > 0:
+				i := rng.Intn(len(alive))
+				events = append(events, unionfind.ConnectivityEvent[int]{Kind: unionfind.RemoveEdgeEvent, A: alive[i][1], B: alive[i][0]})
+				alive = append(alive[:i], alive[i+1:]...)
+			case r < 9:
+				events = append(events, unionfind.ConnectivityEvent[int]{Kind: unionfind.ConnectedEvent, A: rng.Intn(n), B: rng.Intn(n)})
+			default:
+				events = append(events, unionfind.ConnectivityEvent[int]{Kind: unionfind.ComponentsEvent})
+			}
+		}
+
+		answers := unionfind.SolveConnectivity(events)
+		for _, answer := range answers {
+			uf := unionfind.NewUnionFind(n)
+			seen := make(map[int]bool)
+			counts := make(map[[2]int]int)
+			for _, event := range events[:answer.Event+1] {
+				if event.Kind == unionfind.ComponentsEvent {
+					continue
+				}
+
+				seen[event.A], seen[event.B] = true, true
+				edge := [2]int{min(event.A, event.B), max(event.A, event.B)}
+				switch event.Kind {
+				case unionfind.AddEdgeEvent:
+					counts[edge]++
+				case unionfind.RemoveEdgeEvent:
+					counts[edge]--
+				}
+			}
+
+			for element := range seen {
+				uf.Find(element)
+			}
+			for edge, count := range counts {
+				if count > 0 {
+					uf.Union(edge[0], edge[1])
+				}
+			}
+
+			query := events[answer.Event]
+			if query.Kind == unionfind.ConnectedEvent {
+				require.Equal(t, uf.Connected(query.A, query.B), answer.Connected, "event %d", answer.Event)
+			}
+
+			roots := make(map[int]bool)
+			for element := range seen {
+				roots[uf.Find(element)] = true
+			}
+			require.Equal(t, len(roots), answer.Components, "event %d", answer.Event)
+		}
+	})
+}
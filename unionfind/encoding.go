@@ -0,0 +1,885 @@
+package unionfind
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// Binary encoding of the union-find structures.
+// Every encoding starts with a header made of the magic bytes "BPUF",
+// a format version and the kind of structure that was encoded.
+// The body is made of varints, bools as single bytes and
+// gob encoded value dictionaries, each prefixed by their length.
+
+const (
+	encodingMagic   = "BPUF"
+	encodingVersion = 1
+)
+
+type encodingKind byte
+
+const (
+	kindUnionFind encodingKind = iota + 1
+	kindBipartiteUnionFind
+	kindEnumeratedValues
+	kindUnionFindWithValues
+	kindBipartiteUnionFindWithValues
+	kindMappedUnionFind
+	kindMappedValueLog
+	kindMappedValueOffsets
+	kindMappedValueTable
+	kindMappedRejectedEdges
+	kindCompactUnionFind
+)
+
+var (
+	// ErrInvalidEncoding is returned when decoding data that was not produced by MarshalBinary
+	ErrInvalidEncoding = errors.New("unionfind: invalid encoding")
+	// ErrUnsupportedVersion is returned when decoding data written by an unknown format version
+	ErrUnsupportedVersion = errors.New("unionfind: unsupported encoding version")
+	// ErrRootCmpNotEncodable is returned when encoding a structure whose roots are chosen
+	// by a function given to SetRootCmp, since functions can't be encoded
+	ErrRootCmpNotEncodable = errors.New("unionfind: root comparison function can't be encoded")
+)
+
+type encoder struct {
+	buf []byte
+}
+
+func newEncoder(kind encodingKind) *encoder {
+	e := &encoder{}
+	e.buf = append(e.buf, encodingMagic...)
+	e.buf = append(e.buf, encodingVersion, byte(kind))
+	return e
+}
+
+func (e *encoder) uint(v int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(v)) //nolint:gosec // lengths and indices are never negative
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) bytes(v []byte) {
+	e.uint(len(v))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) ints(v []int) {
+	e.uint(len(v))
+	for _, n := range v {
+		e.uint(n)
+	}
+}
+
+// int32s writes v as signed varints, since the parents of CompactUnionFind can be negative
+func (e *encoder) int32s(v []int32) {
+	e.uint(len(v))
+	for _, n := range v {
+		e.buf = binary.AppendVarint(e.buf, int64(n))
+	}
+}
+
+func (e *encoder) bools(v []bool) {
+	e.uint(len(v))
+	for _, b := range v {
+		e.bool(b)
+	}
+}
+
+// decoder reads values written by encoder.
+// The first error encountered is kept and all later reads return zero values.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func newDecoder(data []byte, kind encodingKind) *decoder {
+	d := &decoder{data: data}
+	if len(data) < len(encodingMagic)+2 || string(data[:len(encodingMagic)]) != encodingMagic {
+		d.fail(ErrInvalidEncoding)
+		return d
+	}
+
+	version, got := data[len(encodingMagic)], encodingKind(data[len(encodingMagic)+1])
+	switch {
+	case version != encodingVersion:
+		d.fail(fmt.Errorf("%w: %d", ErrUnsupportedVersion, version))
+	case got != kind:
+		d.fail(fmt.Errorf("%w: unexpected structure kind %d", ErrInvalidEncoding, got))
+	default:
+		d.data = data[len(encodingMagic)+2:]
+	}
+
+	return d
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+		d.data = nil
+	}
+}
+
+func (d *decoder) uint() int {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 || v > math.MaxInt {
+		d.fail(ErrInvalidEncoding)
+		return 0
+	}
+
+	d.data = d.data[n:]
+	return int(v)
+}
+
+// length reads a slice length, checking that at least one byte
+// per element remains so corrupt input can't trigger huge allocations
+func (d *decoder) length() int {
+	n := d.uint()
+	if n > len(d.data) {
+		d.fail(ErrInvalidEncoding)
+		return 0
+	}
+
+	return n
+}
+
+func (d *decoder) bool() bool {
+	if d.err != nil {
+		return false
+	}
+
+	if len(d.data) == 0 || d.data[0] > 1 {
+		d.fail(ErrInvalidEncoding)
+		return false
+	}
+
+	v := d.data[0] == 1
+	d.data = d.data[1:]
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.length()
+	if d.err != nil {
+		return nil
+	}
+
+	v := d.data[:n]
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) ints() []int {
+	v := make([]int, d.length())
+	for i := range v {
+		v[i] = d.uint()
+	}
+
+	return v
+}
+
+func (d *decoder) int32s() []int32 {
+	v := make([]int32, d.length())
+	for i := range v {
+		if d.err != nil {
+			return nil
+		}
+
+		n, size := binary.Varint(d.data)
+		if size <= 0 || n < math.MinInt32 || n > math.MaxInt32 {
+			d.fail(ErrInvalidEncoding)
+			return nil
+		}
+
+		v[i] = int32(n)
+		d.data = d.data[size:]
+	}
+
+	return v
+}
+
+func (d *decoder) bools() []bool {
+	v := make([]bool, d.length())
+	for i := range v {
+		v[i] = d.bool()
+	}
+
+	return v
+}
+
+// finish reports the first error encountered, or an error if there is unread data left
+func (d *decoder) finish() error {
+	if d.err == nil && len(d.data) > 0 {
+		d.fail(ErrInvalidEncoding)
+	}
+
+	return d.err
+}
+
+func (uf *UnionFind) encode(e *encoder) error {
+	if uf.rootPolicy == cmpRoot {
+		return ErrRootCmpNotEncodable
+	}
+
+	e.ints(uf.Root)
+	e.ints(uf.Rank)
+	e.bools(uf.Initialized)
+	e.uint(uf.RootCount)
+	e.uint(uf.NonSingletonCount)
+	e.uint(int(uf.rootPolicy))
+	e.ints(uf.representative)
+	e.ints(uf.addedAt)
+	e.uint(uf.maxSetSize)
+	encodeEdges(e, uf.rejected)
+	encodeProvenance(e, uf.provenance)
+	encodeRetained(e, uf.retained)
+	encodeChanges(e, &uf.changeLog)
+
+	return nil
+}
+
+func (uf *UnionFind) decode(d *decoder) {
+	root := d.ints()
+	rank := d.ints()
+	initialized := d.bools()
+	rootCount := d.uint()
+	nonSingletonCount := d.uint()
+	policy := RootPolicy(d.uint())
+	representative := d.ints()
+	addedAt := d.ints()
+	maxSetSize := d.uint()
+	rejected := decodeEdges(d)
+	forest := decodeProvenance(d)
+	retained := decodeRetained(d)
+	log := decodeChanges(d)
+	if d.err != nil {
+		return
+	}
+
+	if len(rank) != len(root) || len(initialized) != len(root) ||
+		policy < RankRoot || policy > FirstSeenRoot ||
+		(policy != RankRoot && len(representative) != len(root)) ||
+		(policy == FirstSeenRoot && len(addedAt) != len(root)) {
+		d.fail(ErrInvalidEncoding)
+		return
+	}
+	for i, parent := range root {
+		if parent >= len(root) || (initialized[i] && !initialized[parent]) ||
+			(policy != RankRoot && representative[i] >= len(root)) {
+			d.fail(ErrInvalidEncoding)
+			return
+		}
+	}
+	if !acyclic(len(root), func(i int) int {
+		if !initialized[i] || root[i] == i {
+			return -1
+		}
+		return root[i]
+	}) {
+		d.fail(ErrInvalidEncoding)
+		return
+	}
+	checkChanges(d, log, len(root), retained)
+	// The first end of a rejected edge is a U for BipartiteUnionFind, so it's checked by the caller
+	for _, edge := range rejected {
+		if edge[1] >= len(root) {
+			d.fail(ErrInvalidEncoding)
+			return
+		}
+	}
+	if d.err != nil {
+		return
+	}
+
+	uf.Root = root
+	uf.Rank = rank
+	uf.Initialized = initialized
+	uf.RootCount = rootCount
+	uf.NonSingletonCount = nonSingletonCount
+
+	uf.rootPolicy = policy
+	uf.prefer = uf.preferenceFor(policy)
+	uf.representative = nil
+	uf.addedAt = nil
+	if policy != RankRoot {
+		uf.representative = representative
+	}
+	if policy == FirstSeenRoot {
+		uf.addedAt = addedAt
+	}
+
+	uf.maxSetSize = maxSetSize
+	uf.rejected = rejected
+	uf.provenance = forest
+	uf.retained = retained
+	uf.changeLog = log
+}
+
+func encodeEdges(e *encoder, edges [][2]int) {
+	e.uint(len(edges))
+	for _, edge := range edges {
+		e.uint(edge[0])
+		e.uint(edge[1])
+	}
+}
+
+func decodeEdges(d *decoder) [][2]int {
+	var edges [][2]int
+	for range d.length() {
+		edges = append(edges, [2]int{d.uint(), d.uint()})
+	}
+
+	return edges
+}
+
+func encodeProvenance(e *encoder, forest *provenance) {
+	e.bool(forest != nil)
+	if forest == nil {
+		return
+	}
+
+	e.uint(len(forest.edges))
+	for i, edge := range forest.edges {
+		e.uint(edge[0])
+		e.uint(edge[1])
+		e.uint(forest.nodes[i][0])
+		e.uint(forest.nodes[i][1])
+	}
+}
+
+func decodeProvenance(d *decoder) *provenance {
+	if !d.bool() {
+		return nil
+	}
+
+	forest := newProvenance()
+	for range d.length() {
+		edge := [2]int{d.uint(), d.uint()}
+		forest.record(edge, d.uint(), d.uint())
+	}
+
+	return forest
+}
+
+func encodeRetained(e *encoder, retained *retainedEdges) {
+	e.bool(retained != nil)
+	if retained == nil {
+		return
+	}
+
+	edges := slices.SortedFunc(maps.Keys(retained.counts), func(a, b [2]int) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	e.uint(len(edges))
+	for _, edge := range edges {
+		e.uint(edge[0])
+		e.uint(edge[1])
+		e.uint(retained.counts[edge])
+	}
+}
+
+func decodeRetained(d *decoder) *retainedEdges {
+	if !d.bool() {
+		return nil
+	}
+
+	retained := newRetainedEdges()
+	for range d.length() {
+		a, b, count := d.uint(), d.uint(), d.uint()
+		if a == b || count == 0 {
+			d.fail(ErrInvalidEncoding)
+			break
+		}
+		retained.add(a, b, count)
+	}
+
+	return retained
+}
+
+func encodeChanges(e *encoder, log *changeLog) {
+	e.bool(log.rollback)
+	e.uint(log.recorded)
+	e.uint(log.generation)
+	e.uint(len(log.changes))
+	for _, c := range log.changes {
+		e.uint(c.index)
+		// Offset by one since the parent of an added element is -1
+		e.uint(c.parent + 1)
+		e.uint(c.representative)
+		e.bool(c.retained)
+		e.uint(c.seq)
+		e.uint(c.size)
+	}
+}
+
+func decodeChanges(d *decoder) changeLog {
+	log := changeLog{
+		rollback:   d.bool(),
+		recorded:   d.uint(),
+		generation: d.uint(),
+	}
+	for range d.length() {
+		c := change{index: d.uint(), parent: d.uint() - 1, representative: d.uint()}
+		c.retained = d.bool()
+		c.seq = d.uint()
+		c.size = d.uint()
+		log.changes = append(log.changes, c)
+	}
+
+	return log
+}
+
+// checkChanges fails unless the recorded changes refer to elements below n
+// and only retained edges refer to the retained edges
+func checkChanges(d *decoder, log changeLog, n int, retained *retainedEdges) {
+	for _, c := range log.changes {
+		if c.index >= n || c.parent >= n || c.representative >= n || c.size > n ||
+			c.seq >= log.recorded || (c.retained && (retained == nil || c.parent < 0)) {
+			d.fail(ErrInvalidEncoding)
+			return
+		}
+	}
+}
+
+// acyclic reports whether following parent from any of n elements reaches a root,
+// for which parent returns -1, rather than going round a cycle.
+// Each element is walked over once, so it takes O(n) time.
+func acyclic(n int, parent func(i int) int) bool {
+	const (
+		unvisited = iota
+		walking
+		reachesRoot
+	)
+
+	state := make([]byte, n)
+	var path []int
+	for start := range n {
+		path = path[:0]
+		i := start
+		for i >= 0 && state[i] == unvisited {
+			state[i] = walking
+			path = append(path, i)
+			i = parent(i)
+		}
+		if i >= 0 && state[i] == walking {
+			return false
+		}
+		for _, j := range path {
+			state[j] = reachesRoot
+		}
+	}
+
+	return true
+}
+
+// checkRejectedFrom fails unless the first end of every rejected edge is below n
+func (uf *UnionFind) checkRejectedFrom(d *decoder, n int) {
+	for _, edge := range uf.rejected {
+		if edge[0] >= n {
+			d.fail(ErrInvalidEncoding)
+			return
+		}
+	}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (uf *UnionFind) MarshalBinary() ([]byte, error) {
+	e := newEncoder(kindUnionFind)
+	if err := uf.encode(e); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The restored structure continues exactly where the encoded one stopped.
+func (uf *UnionFind) UnmarshalBinary(data []byte) error {
+	restored := &UnionFind{}
+
+	d := newDecoder(data, kindUnionFind)
+	restored.decode(d)
+	restored.checkRejectedFrom(d, len(restored.Root))
+	if err := d.finish(); err != nil {
+		return err
+	}
+
+	*uf = *restored
+	return nil
+}
+
+func (buf *BipartiteUnionFind) encode(e *encoder) error {
+	if err := buf.UnionFind.encode(e); err != nil {
+		return err
+	}
+
+	e.ints(buf.lastRootForUInV)
+	e.bools(buf.lastRootForUInVInitialized)
+	e.uint(buf.maxVDegree)
+	e.ints(buf.vDegree)
+	counted := slices.SortedFunc(maps.Keys(buf.counted), func(a, b [2]int) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	e.uint(len(counted))
+	for _, relation := range counted {
+		e.uint(relation[0])
+		e.uint(relation[1])
+	}
+	e.uint(len(buf.deferred))
+	for _, relation := range buf.deferred {
+		e.uint(relation[0])
+		e.uint(relation[1])
+	}
+	return nil
+}
+
+func (buf *BipartiteUnionFind) decode(d *decoder) {
+	buf.UnionFind.decode(d)
+
+	lastRoot := d.ints()
+	lastRootInitialized := d.bools()
+	maxVDegree := d.uint()
+	vDegree := d.ints()
+	counted := make(map[[2]int]struct{})
+	for range d.length() {
+		counted[[2]int{d.uint(), d.uint()}] = struct{}{}
+	}
+	deferred := make([][2]int, d.length())
+	for i := range deferred {
+		deferred[i] = [2]int{d.uint(), d.uint()}
+	}
+	if d.err != nil {
+		return
+	}
+
+	if len(lastRootInitialized) != len(lastRoot) {
+		d.fail(ErrInvalidEncoding)
+		return
+	}
+	for u, root := range lastRoot {
+		if lastRootInitialized[u] && !buf.Contains(root) {
+			d.fail(ErrInvalidEncoding)
+			return
+		}
+	}
+	for _, relation := range deferred {
+		if !buf.Contains(relation[1]) {
+			d.fail(ErrInvalidEncoding)
+			return
+		}
+	}
+
+	buf.lastRootForUInV = lastRoot
+	buf.lastRootForUInVInitialized = lastRootInitialized
+	buf.maxVDegree = maxVDegree
+	buf.vDegree = nil
+	if len(vDegree) > 0 {
+		buf.vDegree = vDegree
+	}
+	buf.counted = nil
+	if len(counted) > 0 {
+		buf.counted = counted
+	}
+	buf.deferred = nil
+	if len(deferred) > 0 {
+		buf.deferred = deferred
+	}
+	buf.checkRejectedFrom(d, len(lastRoot))
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (buf *BipartiteUnionFind) MarshalBinary() ([]byte, error) {
+	e := newEncoder(kindBipartiteUnionFind)
+	if err := buf.encode(e); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The restored structure continues exactly where the encoded one stopped.
+func (buf *BipartiteUnionFind) UnmarshalBinary(data []byte) error {
+	restored := &BipartiteUnionFind{UnionFind: &UnionFind{}}
+
+	d := newDecoder(data, kindBipartiteUnionFind)
+	restored.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+
+	*buf = *restored
+	return nil
+}
+
+func (uf *CompactUnionFind) encode(e *encoder) {
+	e.int32s(uf.Parent)
+	e.uint(uf.RootCount)
+	e.uint(uf.NonSingletonCount)
+	e.uint(int(uf.rootPolicy))
+	e.int32s(uf.representative)
+	e.int32s(uf.addedAt)
+	e.uint(uf.maxSetSize)
+	encodeEdges(e, uf.rejected)
+	encodeProvenance(e, uf.provenance)
+	encodeRetained(e, uf.retained)
+	encodeChanges(e, &uf.changeLog)
+}
+
+func (uf *CompactUnionFind) decode(d *decoder) {
+	parents := d.int32s()
+	rootCount := d.uint()
+	nonSingletonCount := d.uint()
+	policy := RootPolicy(d.uint())
+	representative := d.int32s()
+	addedAt := d.int32s()
+	maxSetSize := d.uint()
+	rejected := decodeEdges(d)
+	forest := decodeProvenance(d)
+	retained := decodeRetained(d)
+	log := decodeChanges(d)
+	if d.err != nil {
+		return
+	}
+
+	n := len(parents)
+	if n > maxCompactIndex+1 || policy < RankRoot || policy > FirstSeenRoot ||
+		(policy != RankRoot && len(representative) != n) ||
+		(policy == FirstSeenRoot && len(addedAt) != n) {
+		d.fail(ErrInvalidEncoding)
+		return
+	}
+	for i, parent := range parents {
+		if int(parent) > n || (parent > 0 && parents[parent-1] == 0) ||
+			(policy != RankRoot && (representative[i] < 0 || int(representative[i]) >= n)) {
+			d.fail(ErrInvalidEncoding)
+			return
+		}
+	}
+	if !acyclic(n, func(i int) int { return int(parents[i]) - 1 }) {
+		d.fail(ErrInvalidEncoding)
+		return
+	}
+	checkChanges(d, log, n, retained)
+	for _, edge := range rejected {
+		if edge[0] >= n || edge[1] >= n {
+			d.fail(ErrInvalidEncoding)
+			return
+		}
+	}
+	if d.err != nil {
+		return
+	}
+
+	uf.Parent = parents
+	uf.RootCount = rootCount
+	uf.NonSingletonCount = nonSingletonCount
+
+	uf.rootPolicy = policy
+	uf.prefer = uf.preferenceFor(policy)
+	uf.representative = nil
+	uf.addedAt = nil
+	if policy != RankRoot {
+		uf.representative = representative
+	}
+	if policy == FirstSeenRoot {
+		uf.addedAt = addedAt
+	}
+
+	uf.maxSetSize = maxSetSize
+	uf.rejected = rejected
+	uf.provenance = forest
+	uf.retained = retained
+	uf.changeLog = log
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (uf *CompactUnionFind) MarshalBinary() ([]byte, error) {
+	e := newEncoder(kindCompactUnionFind)
+	uf.encode(e)
+	return e.buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The restored structure continues exactly where the encoded one stopped.
+func (uf *CompactUnionFind) UnmarshalBinary(data []byte) error {
+	restored := &CompactUnionFind{}
+
+	d := newDecoder(data, kindCompactUnionFind)
+	restored.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+
+	*uf = *restored
+	return nil
+}
+
+func (ev *EnumeratedValues[T]) encode(e *encoder) error {
+	var values bytes.Buffer
+	if err := gob.NewEncoder(&values).Encode(ev.IndexedElements); err != nil {
+		return fmt.Errorf("unionfind: encoding values: %w", err)
+	}
+
+	e.bytes(values.Bytes())
+	e.ints(ev.free)
+	return nil
+}
+
+func (ev *EnumeratedValues[T]) decode(d *decoder) {
+	data := d.bytes()
+	free := d.ints()
+	if d.err != nil {
+		return
+	}
+
+	var elements []T
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&elements); err != nil {
+		d.fail(fmt.Errorf("%w: decoding values: %w", ErrInvalidEncoding, err))
+		return
+	}
+
+	freed := make(map[int]bool, len(free))
+	for _, i := range free {
+		if i >= len(elements) || freed[i] {
+			d.fail(ErrInvalidEncoding)
+			return
+		}
+		freed[i] = true
+	}
+
+	indices := make(map[T]int, len(elements))
+	for i, element := range elements {
+		if freed[i] {
+			continue
+		}
+		if _, ok := indices[element]; ok {
+			d.fail(fmt.Errorf("%w: duplicate value %v", ErrInvalidEncoding, element))
+			return
+		}
+		indices[element] = i
+	}
+
+	ev.ElementIndices = indices
+	ev.IndexedElements = elements
+	ev.lastIndex = len(elements) - 1
+	ev.free = nil
+	if len(free) > 0 {
+		ev.free = free
+	}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// Values are encoded with encoding/gob, so T must be gob encodable
+// and interface types must be registered with gob.Register.
+func (ev *EnumeratedValues[T]) MarshalBinary() ([]byte, error) {
+	e := newEncoder(kindEnumeratedValues)
+	if err := ev.encode(e); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (ev *EnumeratedValues[T]) UnmarshalBinary(data []byte) error {
+	restored := &EnumeratedValues[T]{}
+
+	d := newDecoder(data, kindEnumeratedValues)
+	restored.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+
+	*ev = *restored
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// See EnumeratedValues.MarshalBinary for the requirements on T.
+func (uf *AlgoUnionFindWithValues[T]) MarshalBinary() ([]byte, error) {
+	e := newEncoder(kindUnionFindWithValues)
+	if err := uf.UnionFind.encode(e); err != nil {
+		return nil, err
+	}
+	if err := uf.values.encode(e); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The restored structure continues exactly where the encoded one stopped.
+func (uf *AlgoUnionFindWithValues[T]) UnmarshalBinary(data []byte) error {
+	restored := &AlgoUnionFindWithValues[T]{
+		UnionFind: &UnionFind{},
+		values:    &EnumeratedValues[T]{},
+	}
+
+	d := newDecoder(data, kindUnionFindWithValues)
+	restored.UnionFind.decode(d)
+	restored.checkRejectedFrom(d, len(restored.Root))
+	restored.values.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+
+	*uf = *restored
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// See EnumeratedValues.MarshalBinary for the requirements on U and V.
+func (buf *BipartiteUnionFindWithValues[U, V]) MarshalBinary() ([]byte, error) {
+	e := newEncoder(kindBipartiteUnionFindWithValues)
+	if err := buf.BipartiteUnionFind.encode(e); err != nil {
+		return nil, err
+	}
+	if err := buf.UValues.encode(e); err != nil {
+		return nil, err
+	}
+	if err := buf.VValues.encode(e); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The restored structure continues exactly where the encoded one stopped.
+func (buf *BipartiteUnionFindWithValues[U, V]) UnmarshalBinary(data []byte) error {
+	restored := &BipartiteUnionFindWithValues[U, V]{
+		BipartiteUnionFind: &BipartiteUnionFind{UnionFind: &UnionFind{}},
+		UValues:            &EnumeratedValues[U]{},
+		VValues:            &EnumeratedValues[V]{},
+	}
+
+	d := newDecoder(data, kindBipartiteUnionFindWithValues)
+	restored.BipartiteUnionFind.decode(d)
+	restored.UValues.decode(d)
+	restored.VValues.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+
+	*buf = *restored
+	return nil
+}
@@ -0,0 +1,206 @@
+package unionfind_test
+
+import (
+	"encoding"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*unionfind.UnionFind)(nil)
+	_ encoding.BinaryUnmarshaler = (*unionfind.UnionFind)(nil)
+	_ encoding.BinaryMarshaler   = (*unionfind.BipartiteUnionFind)(nil)
+	_ encoding.BinaryUnmarshaler = (*unionfind.BipartiteUnionFind)(nil)
+	_ encoding.BinaryMarshaler   = (*unionfind.EnumeratedValues[string])(nil)
+	_ encoding.BinaryUnmarshaler = (*unionfind.EnumeratedValues[string])(nil)
+	_ encoding.BinaryMarshaler   = (*unionfind.AlgoUnionFindWithValues[string])(nil)
+	_ encoding.BinaryUnmarshaler = (*unionfind.AlgoUnionFindWithValues[string])(nil)
+	_ encoding.BinaryMarshaler   = (*unionfind.BipartiteUnionFindWithValues[string, string])(nil)
+	_ encoding.BinaryUnmarshaler = (*unionfind.BipartiteUnionFindWithValues[string, string])(nil)
+)
+
+func TestBinaryEncoding(t *testing.T) {
+	t.Parallel()
+
+	t.Run("UnionFind", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		uf.Union(3, 4)
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.UnionFind
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, uf, &restored)
+
+		assert.Equal(t, uf.Union(4, 2), restored.Union(4, 2))
+		assert.Equal(t, uf.Union(4, 7), restored.Union(4, 7))
+		assert.Equal(t, uf, &restored)
+	})
+
+	t.Run("BipartiteUnionFind", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFind(0)
+		uf.Union(1, 2)
+		uf.Union(1, 3)
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.BipartiteUnionFind
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, uf, &restored)
+
+		assert.Equal(t, uf.Union(1, 6), restored.Union(1, 6))
+		assert.Equal(t, uf.Union(2, 7), restored.Union(2, 7))
+		assert.Equal(t, uf, &restored)
+	})
+
+	t.Run("EnumeratedValues", func(t *testing.T) {
+		ev := unionfind.NewEnumeratedValues[string](0)
+		ev.FetchIndex("A")
+		ev.FetchIndex("B")
+
+		data, err := ev.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.EnumeratedValues[string]
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, 1, restored.FetchIndex("B"))
+		assert.Equal(t, 2, restored.FetchIndex("C"))
+	})
+
+	t.Run("AlgoUnionFindWithValues", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("alice", "bob")
+		uf.Union("charlie", "dave")
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.AlgoUnionFindWithValues[string]
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, uf, &restored)
+
+		assert.Equal(t, uf.UnionReturningValue("dave", "bob"), restored.UnionReturningValue("dave", "bob"))
+		assert.Equal(t, uf.UnionReturningValue("erin", "bob"), restored.UnionReturningValue("erin", "bob"))
+		assert.Equal(t, uf, &restored)
+	})
+
+	t.Run("BipartiteUnionFindWithValues", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		uf.Union("Stanley McFred", "Rad Corp")
+		uf.Union("Stanley McFred", "Milquetoast Inc")
+		uf.Union("Tom", "McNotDonalds")
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.BipartiteUnionFindWithValues[string, string]
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, uf, &restored)
+
+		assert.Equal(t,
+			uf.UnionReturningValue("Tom", "Milquetoast Inc"),
+			restored.UnionReturningValue("Tom", "Milquetoast Inc"))
+		assert.True(t, restored.UsConnected("Stanley McFred", "Tom"))
+		assert.Equal(t, uf, &restored)
+	})
+
+	t.Run("rejects invalid data", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.UnionFind
+		require.ErrorIs(t, restored.UnmarshalBinary(nil), unionfind.ErrInvalidEncoding)
+		require.ErrorIs(t, restored.UnmarshalBinary(data[:len(data)-1]), unionfind.ErrInvalidEncoding)
+
+		var bipartite unionfind.BipartiteUnionFind
+		require.ErrorIs(t, bipartite.UnmarshalBinary(data), unionfind.ErrInvalidEncoding)
+
+		future := append([]byte{}, data...)
+		future[4] = 99
+		require.ErrorIs(t, restored.UnmarshalBinary(future), unionfind.ErrUnsupportedVersion)
+	})
+
+	t.Run("rejects cyclic parents", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		uf.Root[1], uf.Root[2] = 2, 1
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.UnionFind
+		require.ErrorIs(t, restored.UnmarshalBinary(data), unionfind.ErrInvalidEncoding)
+	})
+
+	t.Run("leaves the receiver unchanged on invalid data", func(t *testing.T) {
+		other := unionfind.NewUnionFind(0)
+		other.Union(5, 6)
+		data, err := other.MarshalBinary()
+		require.NoError(t, err)
+
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		require.ErrorIs(t, uf.UnmarshalBinary(append(data, 0)), unionfind.ErrInvalidEncoding)
+		assert.True(t, uf.Connected(1, 2))
+		assert.False(t, uf.Contains(5))
+
+		bipartite := unionfind.NewBipartiteUnionFind(0)
+		bipartite.Union(1, 2)
+		bipartiteData, err := bipartite.MarshalBinary()
+		require.NoError(t, err)
+		bipartite.Union(3, 4)
+		require.ErrorIs(t, bipartite.UnmarshalBinary(append(bipartiteData, 0)), unionfind.ErrInvalidEncoding)
+		assert.True(t, bipartite.Contains(4))
+
+		values := unionfind.NewEnumeratedValues[string](0)
+		values.FetchIndex("a")
+		valuesData, err := values.MarshalBinary()
+		require.NoError(t, err)
+		values.FetchIndex("b")
+		require.ErrorIs(t, values.UnmarshalBinary(append(valuesData, 0)), unionfind.ErrInvalidEncoding)
+		assert.Equal(t, []string{"a", "b"}, values.IndexedElements)
+	})
+
+	t.Run("rollback mode", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		checkpoint := uf.Checkpoint()
+		uf.Union(2, 3)
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.UnionFind
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, uf, &restored)
+
+		require.NoError(t, restored.Rollback(checkpoint))
+		assert.True(t, restored.Connected(1, 2))
+		assert.False(t, restored.Contains(3))
+	})
+
+	t.Run("maximum set size and rejected edges", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFind(0)
+		uf.SetMaxSetSize(2)
+		uf.Union(1, 2)
+		uf.Union(1, 3)
+		uf.Union(1, 4)
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.BipartiteUnionFind
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, uf, &restored)
+		assert.Equal(t, 2, restored.MaxSetSize())
+		assert.Equal(t, [][2]int{{1, 4}}, restored.RejectedEdges())
+	})
+}
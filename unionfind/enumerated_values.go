@@ -1,5 +1,7 @@
 package unionfind
 
+import "slices"
+
 // EnumeratedValues provides a compact representation of
 // the roots in the unionfind structure while still being able to
 // map the roots back to the original values (T)
@@ -7,6 +9,8 @@ type EnumeratedValues[T comparable] struct {
 	ElementIndices  map[T]int
 	IndexedElements []T
 	lastIndex       int
+	// Indices freed by Remove, reused before new indices are created
+	free []int
 }
 
 // NewEnumeratedValues creates a new EnumeratedValues with the specified size
@@ -24,13 +28,82 @@ func (ev *EnumeratedValues[T]) FetchIndex(element T) int {
 		return idx
 	}
 
+	if len(ev.free) > 0 {
+		idx := ev.free[len(ev.free)-1]
+		ev.free = ev.free[:len(ev.free)-1]
+		ev.ElementIndices[element] = idx
+		ev.IndexedElements[idx] = element
+		return idx
+	}
+
 	ev.lastIndex++
 	ev.ElementIndices[element] = ev.lastIndex
 	ev.IndexedElements = append(ev.IndexedElements, element)
 	return ev.lastIndex
 }
 
+// Lookup returns the index of the given element without creating one
+// if the element has not been enumerated yet
+func (ev *EnumeratedValues[T]) Lookup(element T) (int, bool) {
+	idx, ok := ev.ElementIndices[element]
+	return idx, ok
+}
+
+// Contains reports whether the given element has been enumerated
+func (ev *EnumeratedValues[T]) Contains(element T) bool {
+	_, ok := ev.ElementIndices[element]
+	return ok
+}
+
+// Remove frees the index of the given element, returning it
+// and false if the element has not been enumerated.
+// The index is reused by the next element that is enumerated,
+// until then At returns the zero value for it.
+func (ev *EnumeratedValues[T]) Remove(element T) (int, bool) {
+	idx, ok := ev.ElementIndices[element]
+	if !ok {
+		return -1, false
+	}
+
+	var zero T
+	delete(ev.ElementIndices, element)
+	ev.IndexedElements[idx] = zero
+	ev.free = append(ev.free, idx)
+	return idx, true
+}
+
+// Len returns the number of enumerated elements
+func (ev *EnumeratedValues[T]) Len() int {
+	return len(ev.ElementIndices)
+}
+
 // At returns the element at the given index
 func (ev *EnumeratedValues[T]) At(index int) T {
 	return ev.IndexedElements[index]
 }
+
+// AtEach returns the elements at the given indices
+func (ev *EnumeratedValues[T]) AtEach(indices []int) []T {
+	elements := make([]T, len(indices))
+	for i, index := range indices {
+		elements[i] = ev.IndexedElements[index]
+	}
+
+	return elements
+}
+
+// truncate removes every element with an index of n or more
+func (ev *EnumeratedValues[T]) truncate(n int) {
+	var zero T
+	for i := n; i < len(ev.IndexedElements); i++ {
+		// Freed indices hold the zero value, which may be enumerated at another index
+		if idx, ok := ev.ElementIndices[ev.IndexedElements[i]]; ok && idx == i {
+			delete(ev.ElementIndices, ev.IndexedElements[i])
+		}
+		ev.IndexedElements[i] = zero
+	}
+
+	ev.IndexedElements = ev.IndexedElements[:n]
+	ev.lastIndex = n - 1
+	ev.free = slices.DeleteFunc(ev.free, func(idx int) bool { return idx >= n })
+}
@@ -0,0 +1,50 @@
+package unionfind_test
+
+import (
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnumeratedValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("FetchIndex/Lookup", func(t *testing.T) {
+		ev := unionfind.NewEnumeratedValues[string](0)
+		assert.Equal(t, 0, ev.FetchIndex("A"))
+		assert.Equal(t, 1, ev.FetchIndex("B"))
+		assert.Equal(t, 0, ev.FetchIndex("A"))
+
+		idx, ok := ev.Lookup("B")
+		assert.True(t, ok)
+		assert.Equal(t, 1, idx)
+		assert.True(t, ev.Contains("B"))
+
+		_, ok = ev.Lookup("C")
+		assert.False(t, ok)
+		assert.False(t, ev.Contains("C"))
+		assert.Len(t, ev.IndexedElements, 2, "lookups should not add elements")
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		ev := unionfind.NewEnumeratedValues[string](0)
+		ev.FetchIndex("A")
+		ev.FetchIndex("B")
+
+		idx, ok := ev.Remove("A")
+		assert.True(t, ok)
+		assert.Equal(t, 0, idx)
+		assert.False(t, ev.Contains("A"))
+		assert.Equal(t, 1, ev.Len())
+		assert.Equal(t, "", ev.At(0))
+
+		_, ok = ev.Remove("A")
+		assert.False(t, ok)
+
+		assert.Equal(t, 0, ev.FetchIndex("C"), "freed indices are reused")
+		assert.Equal(t, 2, ev.FetchIndex("D"))
+		assert.Equal(t, 3, ev.Len())
+	})
+}
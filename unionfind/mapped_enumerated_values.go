@@ -0,0 +1,269 @@
+//go:build unix
+
+package unionfind
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+)
+
+// Counters in the headers of the files of a MappedEnumeratedValues
+const (
+	// Bytes of the log in use, in the header of the log
+	mappedLogSize = 0
+	// Number of values, in the header of the offsets
+	mappedValueCount = 0
+)
+
+// MappedEnumeratedValues is an EnumeratedValues whose values are stored in memory-mapped files
+// in a directory rather than on the heap, so the dictionary can grow larger than memory,
+// with the operating system keeping the parts in use in memory and spilling the rest to disk.
+// Values are appended to a log, with the offset of each value by index in a second file
+// and an open-addressing hash table from values to indices in a third.
+// Values can't be removed.
+type MappedEnumeratedValues[T ~string] struct {
+	log     *mappedFile
+	offsets *mappedFile
+	table   *mappedFile
+
+	// Offset of each value in the log by index
+	offsetAt []int64
+	// Hash table slots, each holding the top half of a value's hash
+	// above its index plus one, or 0 if empty
+	slots []uint64
+}
+
+// OpenMappedEnumeratedValues opens the MappedEnumeratedValues stored in the directory dir,
+// creating the directory and an empty dictionary if it doesn't exist.
+// It returns ErrNotClosed if the dictionary is already open or wasn't closed with Close,
+// see RecoverMappedEnumeratedValues.
+func OpenMappedEnumeratedValues[T ~string](dir string) (*MappedEnumeratedValues[T], error) {
+	return openMappedEnumeratedValues[T](dir, false)
+}
+
+// RecoverMappedEnumeratedValues opens the MappedEnumeratedValues stored in the directory dir
+// like OpenMappedEnumeratedValues, even if it wasn't closed with Close,
+// rebuilding the offsets and the hash table from the log.
+// Values added since the last Sync may have been lost, along with any value
+// the crash cut short. It must not be used on a dictionary that's still open.
+func RecoverMappedEnumeratedValues[T ~string](dir string) (*MappedEnumeratedValues[T], error) {
+	ev, err := openMappedEnumeratedValues[T](dir, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ev.rebuild()
+	return ev, nil
+}
+
+func openMappedEnumeratedValues[T ~string](dir string, recovering bool) (*MappedEnumeratedValues[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // dir is chosen by the caller
+		return nil, fmt.Errorf("unionfind: creating %s: %w", dir, err)
+	}
+
+	ev := &MappedEnumeratedValues[T]{}
+	var err error
+	var opened []*mappedFile
+	for _, f := range []struct {
+		file **mappedFile
+		name string
+		kind encodingKind
+	}{
+		{&ev.log, "values.log", kindMappedValueLog},
+		{&ev.offsets, "values.offsets", kindMappedValueOffsets},
+		{&ev.table, "values.table", kindMappedValueTable},
+	} {
+		if *f.file, err = openMappedFile(filepath.Join(dir, f.name), f.kind, recovering); err != nil {
+			for _, file := range opened {
+				file.release()
+			}
+			return nil, err
+		}
+		opened = append(opened, *f.file)
+	}
+
+	ev.remapOffsets()
+	ev.remapTable()
+	return ev, nil
+}
+
+func (ev *MappedEnumeratedValues[T]) remapOffsets() {
+	ev.offsetAt = mappedSlice[int64](ev.offsets)
+}
+
+func (ev *MappedEnumeratedValues[T]) remapTable() {
+	ev.slots = mappedSlice[uint64](ev.table)
+}
+
+func hashValue[T ~string](value T) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+	return h.Sum64()
+}
+
+// find returns the slot holding value, or the empty slot it would be added in and false
+func (ev *MappedEnumeratedValues[T]) find(value T, hash uint64) (int, bool) {
+	if len(ev.slots) == 0 {
+		return -1, false
+	}
+
+	mask := uint64(len(ev.slots) - 1)
+	for i := hash & mask; ; i = (i + 1) & mask {
+		slot := ev.slots[i]
+		if slot == 0 {
+			return int(i), false //nolint:gosec // slot positions fit in an int
+		}
+		if slot>>32 == hash>>32 && ev.At(int(slot&0xffffffff)-1) == value { //nolint:gosec // indices fit in an int
+			return int(i), true //nolint:gosec // slot positions fit in an int
+		}
+	}
+}
+
+// FetchIndex gets or creates an index for the given element.
+// It panics if the files can't be grown.
+func (ev *MappedEnumeratedValues[T]) FetchIndex(element T) int {
+	hash := hashValue(element)
+	if i, ok := ev.find(element, hash); ok {
+		return int(ev.slots[i]&0xffffffff) - 1 //nolint:gosec // indices fit in an int
+	}
+
+	index := ev.Len()
+	if index > maxCompactIndex {
+		panic("unionfind: too many values for MappedEnumeratedValues")
+	}
+
+	// Append the value to the log
+	size := ev.log.counter(mappedLogSize)
+	record := binary.AppendUvarint(nil, uint64(len(element)))
+	record = append(record, element...)
+	ev.log.grow(size + len(record))
+	copy(ev.log.body()[size:], record)
+	ev.log.setCounter(mappedLogSize, size+len(record))
+
+	if index >= len(ev.offsetAt) {
+		ev.offsets.grow(8 * (index + 1))
+		ev.remapOffsets()
+	}
+	ev.offsetAt[index] = int64(size)
+	ev.offsets.setCounter(mappedValueCount, index+1)
+
+	// Keep the table at most half full so probes stay short
+	if 2*(index+1) > len(ev.slots) {
+		ev.rehash(max(2*len(ev.slots), 1024))
+	} else {
+		i, _ := ev.find(element, hash)
+		ev.slots[i] = hash&^0xffffffff | uint64(index+1) //nolint:gosec // indices are never negative
+	}
+
+	return index
+}
+
+// rehash resizes the hash table to n slots and adds every value to it again
+func (ev *MappedEnumeratedValues[T]) rehash(n int) {
+	ev.table.grow(8 * n)
+	ev.remapTable()
+	clear(ev.slots)
+
+	for index := range ev.Len() {
+		value := ev.At(index)
+		hash := hashValue(value)
+		// A value logged twice before a crash keeps its first index
+		if i, ok := ev.find(value, hash); !ok {
+			ev.slots[i] = hash&^0xffffffff | uint64(index+1) //nolint:gosec // indices are never negative
+		}
+	}
+}
+
+// rebuild reads every complete value back from the log, writing its offset
+// and adding it to a fresh hash table
+func (ev *MappedEnumeratedValues[T]) rebuild() {
+	log := ev.log.body()
+	size := min(ev.log.counter(mappedLogSize), len(log))
+
+	var count, end int
+	for end < size && count <= maxCompactIndex {
+		n, k := binary.Uvarint(log[end:size])
+		if k <= 0 || n > uint64(size-end-k) { //nolint:gosec // sizes are never negative
+			break
+		}
+
+		if count >= len(ev.offsetAt) {
+			ev.offsets.grow(8 * (count + 1))
+			ev.remapOffsets()
+		}
+		ev.offsetAt[count] = int64(end)
+		end += k + int(n) //nolint:gosec // n is checked against the size of the log
+		count++
+	}
+	ev.log.setCounter(mappedLogSize, end)
+	ev.offsets.setCounter(mappedValueCount, count)
+
+	slots := max(len(ev.slots), 1024)
+	for 2*count > slots {
+		slots *= 2
+	}
+	ev.rehash(slots)
+}
+
+// Lookup returns the index of the given element without creating one
+// if the element has not been enumerated yet
+func (ev *MappedEnumeratedValues[T]) Lookup(element T) (int, bool) {
+	i, ok := ev.find(element, hashValue(element))
+	if !ok {
+		return -1, false
+	}
+
+	return int(ev.slots[i]&0xffffffff) - 1, true //nolint:gosec // indices fit in an int
+}
+
+// Contains reports whether the given element has been enumerated
+func (ev *MappedEnumeratedValues[T]) Contains(element T) bool {
+	_, ok := ev.Lookup(element)
+	return ok
+}
+
+// Len returns the number of enumerated elements
+func (ev *MappedEnumeratedValues[T]) Len() int {
+	return ev.offsets.counter(mappedValueCount)
+}
+
+// At returns the element at the given index
+func (ev *MappedEnumeratedValues[T]) At(index int) T {
+	record := ev.log.body()[ev.offsetAt[index]:]
+	n, k := binary.Uvarint(record)
+	return T(record[k : k+int(n)]) //nolint:gosec // lengths are written from ints
+}
+
+// AtEach returns the elements at the given indices
+func (ev *MappedEnumeratedValues[T]) AtEach(indices []int) []T {
+	elements := make([]T, len(indices))
+	for i, index := range indices {
+		elements[i] = ev.At(index)
+	}
+
+	return elements
+}
+
+// Sync flushes the dictionary to disk
+func (ev *MappedEnumeratedValues[T]) Sync() error {
+	return errors.Join(ev.log.sync(), ev.offsets.sync(), ev.table.sync())
+}
+
+// release closes the files of the dictionary without flushing them, see mappedFile.release
+func (ev *MappedEnumeratedValues[T]) release() {
+	ev.log.release()
+	ev.offsets.release()
+	ev.table.release()
+}
+
+// Close flushes the dictionary to disk and closes its files.
+// The dictionary must not be used afterwards.
+func (ev *MappedEnumeratedValues[T]) Close() error {
+	ev.offsetAt = nil
+	ev.slots = nil
+	return errors.Join(ev.log.close(), ev.offsets.close(), ev.table.close())
+}
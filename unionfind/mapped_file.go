@@ -0,0 +1,200 @@
+//go:build unix
+
+package unionfind
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Memory-mapped files back the out-of-core structures.
+// Each file starts with a header made of the magic bytes "BPUF", a format version,
+// the kind of data the file holds, a byte order mark, whether the file was closed cleanly
+// and a few counters, followed by a fixed-size array in native byte order.
+// The operating system pages the array in and out as it's used,
+// so only the parts being worked on need to fit in memory.
+
+const (
+	mappedVersion    = 1
+	mappedHeaderSize = 64
+	// Written in native byte order to detect files from platforms with a different one
+	mappedByteOrderMark = 0x01020304
+	// Offset of the first counter in the header
+	mappedCountersOffset = 16
+)
+
+// ErrNotClosed is returned when opening a memory-mapped structure
+// that is still open or wasn't closed before its process exited,
+// in which case it may have been left half-written and has to be recovered
+var ErrNotClosed = errors.New("unionfind: mapped file was not closed")
+
+// mappedFile is a file mapped into memory in its entirety
+type mappedFile struct {
+	file *os.File
+	kind encodingKind
+	data []byte
+	// Whether the file was marked as closed cleanly when it was opened
+	wasClosed byte
+}
+
+// openMappedFile opens or creates the file at path holding data of the given kind,
+// marking it as open until close is called. Unless recovering,
+// files that weren't closed are refused with ErrNotClosed.
+func openMappedFile(path string, kind encodingKind, recovering bool) (*mappedFile, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644) //nolint:gosec // path is chosen by the caller
+	if err != nil {
+		return nil, fmt.Errorf("unionfind: opening %s: %w", path, err)
+	}
+
+	m := &mappedFile{file: file, kind: kind}
+	if err := m.open(recovering); err != nil {
+		if m.data != nil {
+			_ = syscall.Munmap(m.data)
+		}
+		_ = file.Close()
+		return nil, fmt.Errorf("unionfind: opening %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+func (m *mappedFile) open(recovering bool) error {
+	info, err := m.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == 0 {
+		if err := m.resize(mappedHeaderSize); err != nil {
+			return err
+		}
+		copy(m.data, encodingMagic)
+		m.data[4] = mappedVersion
+		m.data[5] = byte(m.kind)
+		binary.NativeEndian.PutUint32(m.data[8:], mappedByteOrderMark)
+		m.data[12] = 1
+	} else {
+		if info.Size() < mappedHeaderSize {
+			return ErrInvalidEncoding
+		}
+		if err := m.resize(int(info.Size())); err != nil {
+			return err
+		}
+		if err := m.checkHeader(recovering); err != nil {
+			return err
+		}
+	}
+
+	m.wasClosed = m.data[12]
+	m.data[12] = 0 // open
+	return nil
+}
+
+func (m *mappedFile) checkHeader(recovering bool) error {
+	switch {
+	case string(m.data[:4]) != encodingMagic:
+		return ErrInvalidEncoding
+	case m.data[4] != mappedVersion:
+		return ErrUnsupportedVersion
+	case encodingKind(m.data[5]) != m.kind:
+		return fmt.Errorf("%w: unexpected kind %d", ErrInvalidEncoding, m.data[5])
+	case binary.NativeEndian.Uint32(m.data[8:]) != mappedByteOrderMark:
+		return fmt.Errorf("%w: written with a different byte order", ErrInvalidEncoding)
+	case m.data[12] != 1 && !recovering:
+		return ErrNotClosed
+	}
+
+	return nil
+}
+
+// counter returns the i-th counter of the header
+func (m *mappedFile) counter(i int) int {
+	return int(binary.NativeEndian.Uint64(m.data[mappedCountersOffset+8*i:])) //nolint:gosec // counters are written from ints
+}
+
+// setCounter sets the i-th counter of the header
+func (m *mappedFile) setCounter(i, v int) {
+	binary.NativeEndian.PutUint64(m.data[mappedCountersOffset+8*i:], uint64(v)) //nolint:gosec // counters are never negative
+}
+
+// body returns the array following the header
+func (m *mappedFile) body() []byte {
+	return m.data[mappedHeaderSize:]
+}
+
+// mappedSlice returns the body of the file as a slice of E
+func mappedSlice[E int32 | int64 | uint64](file *mappedFile) []E {
+	body := file.body()
+	if len(body) == 0 {
+		return nil
+	}
+
+	var zero E
+	return unsafe.Slice((*E)(unsafe.Pointer(&body[0])), len(body)/int(unsafe.Sizeof(zero)))
+}
+
+// resize grows or shrinks the file to size bytes and maps it again.
+// Slices of the previous mapping must not be used afterwards.
+func (m *mappedFile) resize(size int) error {
+	if m.data != nil {
+		if err := syscall.Munmap(m.data); err != nil {
+			return err
+		}
+		m.data = nil
+	}
+
+	if err := m.file.Truncate(int64(size)); err != nil {
+		return err
+	}
+
+	data, err := syscall.Mmap(int(m.file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED) //nolint:gosec // file descriptors fit in an int
+	if err != nil {
+		return err
+	}
+
+	m.data = data
+	return nil
+}
+
+// grow resizes the file so its body holds at least n bytes,
+// doubling it at least so growing element by element takes amortized constant time.
+// It panics if the file can't be grown since it's called where errors can't be returned.
+func (m *mappedFile) grow(n int) {
+	if n <= len(m.body()) {
+		return
+	}
+
+	if err := m.resize(mappedHeaderSize + max(n, 2*len(m.body()), 4096)); err != nil {
+		panic(fmt.Sprintf("unionfind: growing %s: %v", m.file.Name(), err))
+	}
+}
+
+// sync flushes the file to disk, writing the mapped pages back to it first
+func (m *mappedFile) sync() error {
+	if err := msync(m.data); err != nil {
+		return err
+	}
+
+	return m.file.Sync()
+}
+
+// close marks the file as closed cleanly, flushes it to disk and unmaps it
+func (m *mappedFile) close() error {
+	m.data[12] = 1
+	err := errors.Join(m.sync(), syscall.Munmap(m.data), m.file.Close())
+	m.data = nil
+	return err
+}
+
+// release unmaps and closes the file without flushing it,
+// leaving it marked as closed cleanly only if it was when opened
+func (m *mappedFile) release() {
+	m.data[12] = m.wasClosed
+	_ = syscall.Munmap(m.data)
+	_ = m.file.Close()
+	m.data = nil
+}
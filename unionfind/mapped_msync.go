@@ -0,0 +1,11 @@
+//go:build unix && !linux
+
+package unionfind
+
+import "golang.org/x/sys/unix"
+
+// msync writes the mapped pages of data back to their file,
+// which fsync isn't guaranteed to do for mappings outside Linux
+func msync(data []byte) error {
+	return unix.Msync(data, unix.MS_SYNC)
+}
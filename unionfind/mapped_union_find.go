@@ -0,0 +1,219 @@
+//go:build unix
+
+package unionfind
+
+import "errors"
+
+// Counters in the header of a MappedUnionFind file
+const (
+	mappedRootCount = iota
+	mappedNonSingletonCount
+	mappedMaxSetSize
+)
+
+// Counter in the header of the file of rejected edges, holding the number of edges saved
+const mappedRejectedCount = 0
+
+// MappedUnionFind is a CompactUnionFind whose elements live in a memory-mapped file
+// rather than on the heap, so it can hold more elements than fit in memory
+// and be reopened later to carry on where it left off.
+// The file grows as elements are added. Find and Union panic if it can't be grown,
+// such as when the disk is full.
+// Rejected edges are kept in memory and saved by Sync and Close
+// to a second file named after the first with the suffix ".rejected".
+// Root policies, rollback, provenance and edge retention keep their state in memory
+// and aren't saved, so a reopened structure starts without them.
+// Files are written in native byte order and can't be opened on platforms with a different one.
+type MappedUnionFind struct {
+	*CompactUnionFind
+	file *mappedFile
+	// Edges rejected as of the last save, see save
+	rejectedFile *mappedFile
+}
+
+// OpenMappedUnionFind opens the MappedUnionFind stored in the file at path,
+// creating an empty one if the file doesn't exist.
+// It returns ErrNotClosed if the file is already open or wasn't closed with Close,
+// see RecoverMappedUnionFind.
+func OpenMappedUnionFind(path string) (*MappedUnionFind, error) {
+	return openMappedUnionFind(path, false)
+}
+
+// RecoverMappedUnionFind opens the MappedUnionFind stored in the file at path
+// like OpenMappedUnionFind, even if it wasn't closed with Close, and recounts its sets.
+// Unions and rejected edges since the last Sync may have been lost, and a union
+// the crash interrupted may have been made or not. It must not be used on a file
+// that's still open, and returns ErrInvalidEncoding if the elements are corrupt.
+func RecoverMappedUnionFind(path string) (*MappedUnionFind, error) {
+	uf, err := openMappedUnionFind(path, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uf.recount(); err != nil {
+		uf.release()
+		return nil, err
+	}
+
+	return uf, nil
+}
+
+func openMappedUnionFind(path string, recovering bool) (*MappedUnionFind, error) {
+	file, err := openMappedFile(path, kindMappedUnionFind, recovering)
+	if err != nil {
+		return nil, err
+	}
+
+	rejectedFile, err := openMappedFile(path+".rejected", kindMappedRejectedEdges, recovering)
+	if err != nil {
+		file.release()
+		return nil, err
+	}
+
+	uf := &MappedUnionFind{CompactUnionFind: &CompactUnionFind{}, file: file, rejectedFile: rejectedFile}
+	uf.CompactUnionFind.grow = uf.grow
+	uf.RootCount = file.counter(mappedRootCount)
+	uf.NonSingletonCount = file.counter(mappedNonSingletonCount)
+	uf.maxSetSize = file.counter(mappedMaxSetSize)
+	uf.remap()
+
+	// A crash may have left the counter ahead of the edges written
+	edges := mappedSlice[int64](rejectedFile)
+	for i := range min(rejectedFile.counter(mappedRejectedCount), len(edges)/2) {
+		uf.rejected = append(uf.rejected, [2]int{int(edges[2*i]), int(edges[2*i+1])}) //nolint:gosec // edges are written from ints
+	}
+	rejectedFile.setCounter(mappedRejectedCount, len(uf.rejected))
+
+	return uf, nil
+}
+
+// grow makes room in the file for elements up to index n
+func (uf *MappedUnionFind) grow(n int) {
+	uf.file.grow(4 * (n + 1))
+	uf.remap()
+}
+
+// remap points Parent at the elements in the file
+func (uf *MappedUnionFind) remap() {
+	uf.Parent = mappedSlice[int32](uf.file)
+}
+
+// recount rebuilds the set sizes and counters from the parent of each element,
+// adding the elements a parent points at that were lost
+func (uf *MappedUnionFind) recount() error {
+	n := len(uf.Parent)
+	for i, parent := range uf.Parent {
+		switch {
+		case parent < 0:
+			uf.Parent[i] = -1
+		case parent > 0:
+			if int(parent) > n || int(parent)-1 == i {
+				return ErrInvalidEncoding
+			}
+			if uf.Parent[parent-1] == 0 {
+				uf.Parent[parent-1] = -1
+			}
+		}
+	}
+
+	if !acyclic(n, func(i int) int { return int(uf.Parent[i]) - 1 }) {
+		return ErrInvalidEncoding
+	}
+
+	for i, parent := range uf.Parent {
+		if parent <= 0 {
+			continue
+		}
+
+		root := i
+		for uf.Parent[root] > 0 {
+			root = int(uf.Parent[root]) - 1
+		}
+		uf.Find(i)
+		uf.Parent[root]--
+	}
+
+	uf.RootCount = 0
+	uf.NonSingletonCount = 0
+	for _, parent := range uf.Parent {
+		if parent != 0 {
+			uf.RootCount++
+		}
+		if parent < -1 {
+			uf.NonSingletonCount++
+		}
+	}
+
+	return nil
+}
+
+// save writes the counters to the header of the file
+// and the rejected edges that changed since the last save to the file of rejected edges.
+// Rollbacks and removals can drop rejected edges, so the edges are compared with the file
+// rather than assumed to only have been appended to.
+func (uf *MappedUnionFind) save() {
+	uf.file.setCounter(mappedRootCount, uf.RootCount)
+	uf.file.setCounter(mappedNonSingletonCount, uf.NonSingletonCount)
+	uf.file.setCounter(mappedMaxSetSize, uf.maxSetSize)
+
+	uf.rejectedFile.grow(16 * len(uf.rejected))
+	edges := mappedSlice[int64](uf.rejectedFile)
+	saved := min(uf.rejectedFile.counter(mappedRejectedCount), len(uf.rejected))
+	for i := range saved {
+		if edges[2*i] != int64(uf.rejected[i][0]) || edges[2*i+1] != int64(uf.rejected[i][1]) {
+			saved = i
+			break
+		}
+	}
+
+	for i, edge := range uf.rejected[saved:] {
+		edges[2*(saved+i)] = int64(edge[0])
+		edges[2*(saved+i)+1] = int64(edge[1])
+	}
+	uf.rejectedFile.setCounter(mappedRejectedCount, len(uf.rejected))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, restoring an encoded CompactUnionFind
+// or MappedUnionFind into the file, which is grown to fit it
+func (uf *MappedUnionFind) UnmarshalBinary(data []byte) error {
+	restored := &CompactUnionFind{}
+	if err := restored.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	parents := restored.Parent
+	if len(parents) > 0 {
+		uf.grow(len(parents) - 1)
+	}
+	copy(uf.Parent, parents)
+	clear(uf.Parent[len(parents):])
+
+	restored.Parent = uf.Parent
+	restored.grow = uf.grow
+	*uf.CompactUnionFind = *restored
+	uf.growTo(len(uf.Parent) - 1)
+	uf.save()
+
+	return nil
+}
+
+// release closes the files of the structure without flushing them, see mappedFile.release
+func (uf *MappedUnionFind) release() {
+	uf.Parent = nil
+	uf.file.release()
+	uf.rejectedFile.release()
+}
+
+// Sync flushes the structure to disk
+func (uf *MappedUnionFind) Sync() error {
+	uf.save()
+	return errors.Join(uf.file.sync(), uf.rejectedFile.sync())
+}
+
+// Close flushes the structure to disk and closes its files.
+// The structure must not be used afterwards.
+func (uf *MappedUnionFind) Close() error {
+	uf.save()
+	uf.Parent = nil
+	return errors.Join(uf.file.close(), uf.rejectedFile.close())
+}
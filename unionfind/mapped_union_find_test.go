@@ -0,0 +1,355 @@
+//go:build unix
+
+package unionfind_test
+
+import (
+	"encoding"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMappedUnionFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reopens where it left off", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "parents")
+		uf, err := unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		uf.SetMaxSetSize(3)
+		uf.Union(1, 2)
+		uf.Union(2, 3)
+		uf.Union(3, 4)
+		require.NoError(t, uf.Close())
+
+		uf, err = unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		defer uf.Close()
+
+		assert.True(t, uf.Connected(1, 3))
+		assert.False(t, uf.Connected(1, 4))
+		assert.Equal(t, 4, uf.RootCount)
+		assert.Equal(t, 1, uf.NonSingletonCount)
+		assert.Equal(t, 3, uf.MaxSetSize())
+		assert.Equal(t, [][2]int{{3, 4}}, uf.RejectedEdges())
+
+		uf.Union(4, 5)
+		assert.Equal(t, []int{1, 4}, slices.Collect(uf.Roots()))
+	})
+
+	t.Run("matches CompactUnionFind while growing", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 20000
+
+		path := filepath.Join(t.TempDir(), "parents")
+		uf, err := unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+
+		compact := unionfind.NewCompactUnionFind(0)
+		for i := range n {
+			// Indices mostly grow so the file is remapped along the way
+			a, b := i, rng.Intn(i+1)
+			require.Equal(t, compact.Union(a, b), uf.Union(a, b))
+		}
+		require.NoError(t, uf.Close())
+
+		uf, err = unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		defer uf.Close()
+
+		assert.Equal(t, compact.RootCount, uf.RootCount)
+		assert.Equal(t, compact.NonSingletonCount, uf.NonSingletonCount)
+		for i := range n {
+			require.Equal(t, compact.Find(i), uf.Find(i))
+		}
+	})
+
+	t.Run("saves rejected edges that were rolled back", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "parents")
+		uf, err := unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		uf.SetMaxSetSize(2)
+		uf.UnionAll([][2]int{{0, 1}, {1, 2}}, 0)
+		require.NoError(t, uf.Sync())
+
+		checkpoint := uf.Checkpoint()
+		uf.UnionAll([][2]int{{2, 3}, {3, 1}}, 0)
+		require.NoError(t, uf.Sync())
+		require.NoError(t, uf.Rollback(checkpoint))
+		uf.Union(2, 0)
+		require.NoError(t, uf.Close())
+
+		uf, err = unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		defer uf.Close()
+
+		assert.Equal(t, [][2]int{{1, 2}, {2, 0}}, uf.RejectedEdges())
+		assert.False(t, uf.Contains(3))
+		assert.Equal(t, 3, uf.RootCount)
+	})
+
+	t.Run("unmarshals into the file", func(t *testing.T) {
+		compact := unionfind.NewCompactUnionFind(0)
+		compact.SetMaxSetSize(3)
+		compact.UnionAll([][2]int{{0, 1}, {1, 2}, {2, 3}, {5000, 6}}, 0)
+		data, err := compact.MarshalBinary()
+		require.NoError(t, err)
+
+		path := filepath.Join(t.TempDir(), "parents")
+		uf, err := unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		uf.Union(7000, 8)
+		require.NoError(t, uf.UnmarshalBinary(data))
+		assert.False(t, uf.Contains(7000), "elements beyond the decoded ones are cleared")
+		uf.Union(6, 7)
+		require.NoError(t, uf.Close())
+
+		uf, err = unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		defer uf.Close()
+
+		assert.Equal(t, []int{0, 1, 2}, uf.Members(2))
+		assert.Equal(t, []int{6, 7, 5000}, uf.Members(7))
+		assert.Equal(t, 7, uf.RootCount)
+		assert.Equal(t, 3, uf.MaxSetSize())
+		assert.Equal(t, [][2]int{{2, 3}}, uf.RejectedEdges())
+		assert.ErrorIs(t, uf.UnmarshalBinary(data[:1]), unionfind.ErrInvalidEncoding)
+	})
+
+	t.Run("refuses files that weren't closed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "parents")
+		uf, err := unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		require.NoError(t, uf.Sync())
+
+		_, err = unionfind.OpenMappedUnionFind(path)
+		require.ErrorIs(t, err, unionfind.ErrNotClosed)
+
+		require.NoError(t, uf.Close())
+		uf, err = unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		require.NoError(t, uf.Close())
+	})
+
+	t.Run("recovers files that weren't closed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "parents")
+		uf, err := unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		uf.SetMaxSetSize(3)
+		uf.Union(1, 2)
+		uf.Union(2, 3)
+		uf.Union(3, 4)
+		require.NoError(t, uf.Sync())
+		// Changes after the last Sync may or may not survive a crash,
+		// and the process exits without closing
+		uf.Union(5, 6)
+		uf.Union(5, 1)
+
+		_, err = unionfind.OpenMappedUnionFind(path)
+		require.ErrorIs(t, err, unionfind.ErrNotClosed)
+
+		recovered, err := unionfind.RecoverMappedUnionFind(path)
+		require.NoError(t, err)
+		assert.True(t, recovered.Connected(1, 3))
+		assert.True(t, recovered.Connected(5, 6))
+		assert.Equal(t, 6, recovered.RootCount)
+		assert.Equal(t, 2, recovered.NonSingletonCount)
+		assert.Equal(t, 3, recovered.Size(2))
+		assert.Equal(t, [][2]int{{3, 4}}, recovered.RejectedEdges())
+		require.NoError(t, recovered.Close())
+
+		reopened, err := unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		assert.Equal(t, 6, reopened.RootCount)
+		require.NoError(t, reopened.Close())
+	})
+
+	t.Run("refuses to recover corrupt files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "parents")
+		uf, err := unionfind.OpenMappedUnionFind(path)
+		require.NoError(t, err)
+		uf.Union(0, 1)
+		uf.Parent[0] = 2 // 0 and 1 each other's parent
+		uf.Parent[1] = 1
+
+		_, err = unionfind.RecoverMappedUnionFind(path)
+		require.ErrorIs(t, err, unionfind.ErrInvalidEncoding)
+		_, err = unionfind.OpenMappedUnionFind(path)
+		require.ErrorIs(t, err, unionfind.ErrNotClosed, "a failed recovery leaves the file as it was")
+	})
+
+	t.Run("refuses other files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "parents")
+		require.NoError(t, os.WriteFile(path, make([]byte, 100), 0o600))
+
+		_, err := unionfind.OpenMappedUnionFind(path)
+		require.ErrorIs(t, err, unionfind.ErrInvalidEncoding)
+	})
+}
+
+func TestMappedUnionFindWithValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reopens where it left off", func(t *testing.T) {
+		dir := t.TempDir()
+		uf, err := unionfind.OpenMappedUnionFindWithValues[string](dir)
+		require.NoError(t, err)
+		uf.UnionAll([][2]string{{"alice", "10.0.0.1"}, {"bob", "10.0.0.1"}, {"carol", "10.0.0.2"}}, 1)
+		assert.Equal(t, "alice", uf.FindReturningValue("bob"))
+		require.NoError(t, uf.Close())
+
+		uf, err = unionfind.OpenMappedUnionFindWithValues[string](dir)
+		require.NoError(t, err)
+		defer uf.Close()
+
+		assert.True(t, uf.Connected("alice", "bob"))
+		assert.False(t, uf.Connected("alice", "carol"))
+		assert.False(t, uf.Contains("dave"))
+
+		root, ok := uf.TryFindReturningValue("10.0.0.1")
+		require.True(t, ok)
+		assert.Equal(t, "alice", root)
+
+		assert.Equal(t, "alice", uf.UnionReturningValue("carol", "bob"))
+		assert.Equal(t, []string{"alice", "10.0.0.1", "bob", "carol", "10.0.0.2"}, uf.Members("carol"))
+		assert.Equal(t, 5, uf.SizeOf("10.0.0.2"))
+		assert.Equal(t, []string{"alice"}, uf.LargestSets(1))
+	})
+
+	t.Run("Members and SizeOf don't add values", func(t *testing.T) {
+		uf, err := unionfind.OpenMappedUnionFindWithValues[string](t.TempDir())
+		require.NoError(t, err)
+		defer uf.Close()
+
+		assert.Nil(t, uf.Members("alice"))
+		assert.Equal(t, 0, uf.SizeOf("alice"))
+		assert.False(t, uf.Contains("alice"))
+	})
+
+	t.Run("index-based operations aren't exposed", func(t *testing.T) {
+		uf, err := unionfind.OpenMappedUnionFindWithValues[string](t.TempDir())
+		require.NoError(t, err)
+		defer uf.Close()
+
+		uf.UnionAll([][2]string{{"alice", "10.0.0.1"}, {"bob", "10.0.0.2"}}, 4)
+		assert.Equal(t, 4, uf.RootCount())
+		assert.Equal(t, 2, uf.NonSingletonCount())
+
+		var values any = uf
+		_, ok := values.(interface {
+			Merge(*unionfind.CompactUnionFind)
+		})
+		assert.False(t, ok)
+		_, ok = values.(encoding.BinaryUnmarshaler)
+		assert.False(t, ok)
+		_, ok = values.(interface {
+			Rollback(unionfind.Checkpoint) error
+		})
+		assert.False(t, ok)
+		_, ok = values.(interface{ RemoveElement(int) bool })
+		assert.False(t, ok)
+	})
+
+	t.Run("recovers directories that weren't closed", func(t *testing.T) {
+		dir := t.TempDir()
+		uf, err := unionfind.OpenMappedUnionFindWithValues[string](dir)
+		require.NoError(t, err)
+		uf.SetMaxSetSize(2)
+		uf.UnionAll([][2]string{{"alice", "10.0.0.1"}, {"bob", "10.0.0.1"}, {"carol", "10.0.0.2"}}, 1)
+		require.NoError(t, uf.Sync())
+		uf.Union("dave", "10.0.0.2")
+
+		_, err = unionfind.OpenMappedUnionFindWithValues[string](dir)
+		require.ErrorIs(t, err, unionfind.ErrNotClosed)
+
+		// Lose the hash table, which is rebuilt from the log
+		table, err := os.OpenFile(filepath.Join(dir, "values.table"), os.O_RDWR, 0)
+		require.NoError(t, err)
+		info, err := table.Stat()
+		require.NoError(t, err)
+		_, err = table.WriteAt(make([]byte, info.Size()-64), 64)
+		require.NoError(t, err)
+		require.NoError(t, table.Close())
+
+		recovered, err := unionfind.RecoverMappedUnionFindWithValues[string](dir)
+		require.NoError(t, err)
+		defer recovered.Close()
+
+		assert.True(t, recovered.Connected("alice", "10.0.0.1"))
+		assert.False(t, recovered.Connected("alice", "bob"))
+		assert.Equal(t, []string{"carol", "10.0.0.2"}, recovered.Members("carol"))
+		assert.True(t, recovered.Contains("dave"))
+		assert.Equal(t, [][2]string{{"bob", "10.0.0.1"}}, recovered.RejectedEdges(), "edges rejected since the last Sync are lost")
+
+		recovered.Union("erin", "dave")
+		assert.Equal(t, []string{"dave", "erin"}, recovered.Members("erin"))
+	})
+
+	t.Run("matches AlgoUnionFindWithValues", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 5000
+
+		dir := t.TempDir()
+		uf, err := unionfind.OpenMappedUnionFindWithValues[string](dir)
+		require.NoError(t, err)
+
+		// Enough values to grow the log and rehash the table several times
+		want := unionfind.NewUnionFindWithValues[string](0)
+		for range n {
+			a, b := fmt.Sprintf("user%d", rng.Intn(n)), fmt.Sprintf("ip%d", rng.Intn(n))
+			want.Union(a, b)
+			uf.Union(a, b)
+		}
+		require.NoError(t, uf.Close())
+
+		uf, err = unionfind.OpenMappedUnionFindWithValues[string](dir)
+		require.NoError(t, err)
+		defer uf.Close()
+
+		sets := make(map[string][]string)
+		for root, members := range uf.Sets() {
+			sets[root] = members
+		}
+		for root, members := range want.Sets() {
+			assert.Equal(t, members, sets[root])
+		}
+	})
+}
+
+func TestMappedEnumeratedValues(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ev, err := unionfind.OpenMappedEnumeratedValues[string](dir)
+	require.NoError(t, err)
+
+	const n = 3000
+	for i := range n {
+		require.Equal(t, i, ev.FetchIndex(fmt.Sprint(i)))
+	}
+	assert.Equal(t, 0, ev.FetchIndex("0"))
+	assert.Equal(t, n, ev.FetchIndex(""))
+	require.NoError(t, ev.Close())
+
+	ev, err = unionfind.OpenMappedEnumeratedValues[string](dir)
+	require.NoError(t, err)
+	defer ev.Close()
+
+	assert.Equal(t, n+1, ev.Len())
+	for i := range n {
+		index, ok := ev.Lookup(fmt.Sprint(i))
+		require.True(t, ok)
+		require.Equal(t, i, index)
+	}
+	assert.True(t, ev.Contains(""))
+	assert.False(t, ev.Contains("x"))
+	assert.Equal(t, []string{"12", "", "7"}, ev.AtEach([]int{12, n, 7}))
+}
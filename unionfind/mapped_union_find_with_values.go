@@ -0,0 +1,246 @@
+//go:build unix
+
+package unionfind
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// MappedUnionFindWithValues represents a memory-mapped union-find structure with string values,
+// keeping both its elements and its value dictionary in files in a directory
+// so it can process edge sets larger than memory and be reopened later to carry on.
+// It's a separate type rather than a backend for AlgoUnionFindWithValues,
+// offering its core value-keyed queries and unions along with a maximum set size.
+// Rollback, root policies, provenance, removals and merging only exist on the index-based forms.
+type MappedUnionFindWithValues[T ~string] struct {
+	sets   *MappedUnionFind
+	values *MappedEnumeratedValues[T]
+}
+
+// OpenMappedUnionFindWithValues opens the MappedUnionFindWithValues stored in the directory dir,
+// creating the directory and an empty structure if it doesn't exist.
+// It returns ErrNotClosed if the structure is already open or wasn't closed with Close,
+// see RecoverMappedUnionFindWithValues.
+func OpenMappedUnionFindWithValues[T ~string](dir string) (*MappedUnionFindWithValues[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // dir is chosen by the caller
+		return nil, fmt.Errorf("unionfind: creating %s: %w", dir, err)
+	}
+
+	uf, err := OpenMappedUnionFind(filepath.Join(dir, "parents"))
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := OpenMappedEnumeratedValues[T](dir)
+	if err != nil {
+		uf.release()
+		return nil, err
+	}
+
+	return &MappedUnionFindWithValues[T]{sets: uf, values: values}, nil
+}
+
+// RecoverMappedUnionFindWithValues opens the MappedUnionFindWithValues stored in the directory dir
+// like OpenMappedUnionFindWithValues, even if it wasn't closed with Close,
+// recovering its elements as RecoverMappedUnionFind does and its values
+// as RecoverMappedEnumeratedValues does. Changes since the last Sync may have been lost.
+// It must not be used on a structure that's still open, and returns ErrInvalidEncoding
+// if the elements are corrupt or include elements whose values were lost.
+func RecoverMappedUnionFindWithValues[T ~string](dir string) (*MappedUnionFindWithValues[T], error) {
+	uf, err := RecoverMappedUnionFind(filepath.Join(dir, "parents"))
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := RecoverMappedEnumeratedValues[T](dir)
+	if err != nil {
+		uf.release()
+		return nil, err
+	}
+
+	for i := values.Len(); i < len(uf.Parent); i++ {
+		if uf.Parent[i] != 0 {
+			uf.release()
+			values.release()
+			return nil, fmt.Errorf("%w: element %d has no value", ErrInvalidEncoding, i)
+		}
+	}
+	uf.rejected = slices.DeleteFunc(uf.rejected, func(edge [2]int) bool {
+		return edge[0] >= values.Len() || edge[1] >= values.Len()
+	})
+
+	return &MappedUnionFindWithValues[T]{sets: uf, values: values}, nil
+}
+
+// Find returns the root index of the set containing the given value
+func (uf *MappedUnionFindWithValues[T]) Find(value T) int {
+	return uf.sets.Find(uf.values.FetchIndex(value))
+}
+
+// FindReturningValue returns the root value of the set containing the given value
+func (uf *MappedUnionFindWithValues[T]) FindReturningValue(value T) T {
+	return uf.values.At(uf.Find(value))
+}
+
+// Contains reports whether the given value has been added
+func (uf *MappedUnionFindWithValues[T]) Contains(value T) bool {
+	return uf.values.Contains(value)
+}
+
+// TryFind returns the root index of the set containing the given value
+// and false if the value has not been added. The structure is left unchanged.
+func (uf *MappedUnionFindWithValues[T]) TryFind(value T) (int, bool) {
+	index, ok := uf.values.Lookup(value)
+	if !ok {
+		return -1, false
+	}
+
+	return uf.sets.TryFind(index)
+}
+
+// TryFindReturningValue returns the root value of the set containing the given value
+// and false if the value has not been added. The structure is left unchanged.
+func (uf *MappedUnionFindWithValues[T]) TryFindReturningValue(value T) (T, bool) {
+	root, ok := uf.TryFind(value)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	return uf.values.At(root), true
+}
+
+// Connected reports whether values a and b are in the same set.
+// Values that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *MappedUnionFindWithValues[T]) Connected(a, b T) bool {
+	indexA, okA := uf.values.Lookup(a)
+	indexB, okB := uf.values.Lookup(b)
+	if !okA || !okB {
+		return false
+	}
+
+	return uf.sets.Connected(indexA, indexB)
+}
+
+// Union merges the sets containing values a and b, returning the root index
+func (uf *MappedUnionFindWithValues[T]) Union(a, b T) int {
+	indexA := uf.values.FetchIndex(a)
+	indexB := uf.values.FetchIndex(b)
+
+	return uf.sets.Union(indexA, indexB)
+}
+
+// UnionAll merges the sets containing both values of every pair.
+// Unlike AlgoUnionFindWithValues.UnionAll the pairs are always unioned one at a time in order,
+// and workers is only accepted so the two can be swapped for each other.
+func (uf *MappedUnionFindWithValues[T]) UnionAll(pairs [][2]T, _ int) {
+	for _, pair := range pairs {
+		uf.Union(pair[0], pair[1])
+	}
+}
+
+// UnionReturningValue merges the sets containing values a and b, returning the root value
+func (uf *MappedUnionFindWithValues[T]) UnionReturningValue(a, b T) T {
+	return uf.values.At(uf.Union(a, b))
+}
+
+// RejectedEdges returns the pairs of values whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (uf *MappedUnionFindWithValues[T]) RejectedEdges() [][2]T {
+	edges := uf.sets.RejectedEdges()
+	pairs := make([][2]T, len(edges))
+	for i, edge := range edges {
+		pairs[i] = [2]T{uf.values.At(edge[0]), uf.values.At(edge[1])}
+	}
+
+	return pairs
+}
+
+// Members returns all values in the set containing the given value,
+// or nil if the value has not been added
+func (uf *MappedUnionFindWithValues[T]) Members(value T) []T {
+	index, ok := uf.values.Lookup(value)
+	if !ok {
+		return nil
+	}
+
+	return uf.values.AtEach(uf.sets.Members(index))
+}
+
+// Roots returns an iterator over the root value of every set
+func (uf *MappedUnionFindWithValues[T]) Roots() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for root := range uf.sets.Roots() {
+			if !yield(uf.values.At(root)) {
+				return
+			}
+		}
+	}
+}
+
+// Sets returns an iterator over every set, yielding its root value
+// along with the values of all of its members.
+// The members of every set are gathered in memory first.
+func (uf *MappedUnionFindWithValues[T]) Sets() iter.Seq2[T, []T] {
+	return func(yield func(T, []T) bool) {
+		for root, members := range uf.sets.Sets() {
+			if !yield(uf.values.At(root), uf.values.AtEach(members)) {
+				return
+			}
+		}
+	}
+}
+
+// SizeOf returns the exact number of values in the set containing the given value,
+// or 0 if the value has not been added
+func (uf *MappedUnionFindWithValues[T]) SizeOf(value T) int {
+	index, ok := uf.values.Lookup(value)
+	if !ok {
+		return 0
+	}
+
+	return uf.sets.Size(index)
+}
+
+// LargestSets returns the root values of the k largest sets, largest first
+func (uf *MappedUnionFindWithValues[T]) LargestSets(k int) []T {
+	return uf.values.AtEach(uf.sets.LargestSets(k))
+}
+
+// RootCount returns the number of values that have been added, like the RootCount field of UnionFind
+func (uf *MappedUnionFindWithValues[T]) RootCount() int {
+	return uf.sets.RootCount
+}
+
+// NonSingletonCount returns the number of sets with more than one member
+func (uf *MappedUnionFindWithValues[T]) NonSingletonCount() int {
+	return uf.sets.NonSingletonCount
+}
+
+// SetMaxSetSize limits the size of the sets unions may create, see UnionFind.SetMaxSetSize.
+// The limit is saved with the structure.
+func (uf *MappedUnionFindWithValues[T]) SetMaxSetSize(size int) {
+	uf.sets.SetMaxSetSize(size)
+}
+
+// MaxSetSize returns the largest set a union may create, 0 if there's no limit
+func (uf *MappedUnionFindWithValues[T]) MaxSetSize() int {
+	return uf.sets.MaxSetSize()
+}
+
+// Sync flushes the structure to disk
+func (uf *MappedUnionFindWithValues[T]) Sync() error {
+	return errors.Join(uf.sets.Sync(), uf.values.Sync())
+}
+
+// Close flushes the structure to disk and closes its files.
+// The structure must not be used afterwards.
+func (uf *MappedUnionFindWithValues[T]) Close() error {
+	return errors.Join(uf.sets.Close(), uf.values.Close())
+}
@@ -0,0 +1,145 @@
+package unionfind
+
+import (
+	"maps"
+	"slices"
+)
+
+// Merging unions every element of another structure with the root of its set there,
+// which is enough to recreate its sets without replaying the edges that built them,
+// so structures built separately per shard or per day can be combined map-reduce style.
+// The unions are made like any others, so they are what's recorded as rejected edges,
+// for provenance and for edge retention rather than the edges that built the other structure.
+
+// Merge merges the sets of other into uf, as though the unions made on other
+// had been made on uf, with elements at the same indices. Other is left unchanged.
+func (uf *UnionFind) Merge(other *UnionFind) {
+	for i, initialized := range other.Initialized {
+		if !initialized {
+			continue
+		}
+
+		root, _ := other.TryFind(i)
+		if root == i {
+			uf.addElement(i)
+		} else {
+			uf.Union(i, root)
+		}
+	}
+}
+
+// Merge merges the sets of other into uf, as though the unions made on other
+// had been made on uf, with elements at the same indices. Other is left unchanged.
+func (uf *CompactUnionFind) Merge(other *CompactUnionFind) {
+	for i, parent := range other.Parent {
+		if parent == 0 {
+			continue
+		}
+
+		root, _ := other.tryFindRoot(i)
+		if root == i {
+			uf.addElement(i)
+		} else {
+			uf.Union(i, root)
+		}
+	}
+}
+
+// Merge merges the sets of other into uf, as though the unions made on other
+// had been made on uf. Values are matched by value, so the indices of other don't matter.
+// Other is left unchanged.
+func (uf *AlgoUnionFindWithValues[T]) Merge(other *AlgoUnionFindWithValues[T]) {
+	for i, initialized := range other.Initialized {
+		if !initialized {
+			continue
+		}
+
+		root, _ := other.UnionFind.TryFind(i)
+		if root == i {
+			uf.Find(other.values.At(i))
+		} else {
+			uf.Union(other.values.At(i), other.values.At(root))
+		}
+	}
+}
+
+// Merge merges the sets of other into uf along with their aggregates, as though the unions
+// and additions made on other had been made on uf, with elements at the same indices.
+// The aggregate of each set of other is combined into the set its root ends up in,
+// so if a union is rejected for exceeding the maximum set size
+// the whole aggregate goes to the root's side. Other is left unchanged.
+func (uf *AggregatingUnionFind[A]) Merge(other *AggregatingUnionFind[A]) {
+	for i, initialized := range other.sets.Initialized {
+		if !initialized {
+			continue
+		}
+
+		root, _ := other.sets.TryFind(i)
+		if root == i {
+			uf.sets.addElement(i)
+		} else {
+			uf.Union(i, root)
+		}
+	}
+
+	for _, root := range slices.Sorted(maps.Keys(other.aggregates)) {
+		uf.Add(root, other.aggregates[root])
+	}
+}
+
+// Merge merges the sets of other into uf along with their aggregates, as though the unions
+// and additions made on other had been made on uf, matching values by value
+// like AlgoUnionFindWithValues.Merge. Other is left unchanged.
+func (uf *AggregatingUnionFindWithValues[T, A]) Merge(other *AggregatingUnionFindWithValues[T, A]) {
+	for i, initialized := range other.sets.Initialized {
+		if !initialized {
+			continue
+		}
+
+		root, _ := other.sets.UnionFind.TryFind(i)
+		if root == i {
+			uf.sets.Find(other.sets.values.At(i))
+		} else {
+			uf.Union(other.sets.values.At(i), other.sets.values.At(root))
+		}
+	}
+
+	for _, root := range slices.Sorted(maps.Keys(other.aggregates.aggregates)) {
+		uf.Add(other.sets.values.At(root), other.aggregates.aggregates[root])
+	}
+}
+
+// Merge merges the sets of other into buf, as though the relations made on other
+// had been made on buf, matching values by value. Each V is unioned with the root of its set
+// in other and each U is related to the root it's associated with there.
+// Relations made by the merge don't count towards the degree of a V,
+// while the relations other deferred until Flush are passed to Union like new ones.
+// Other is left unchanged.
+func (buf *BipartiteUnionFindWithValues[U, V]) Merge(other *BipartiteUnionFindWithValues[U, V]) {
+	vIndex := func(v int) int {
+		return buf.VValues.FetchIndex(other.VValues.At(v))
+	}
+
+	for i, initialized := range other.Initialized {
+		if !initialized {
+			continue
+		}
+
+		root, _ := other.UnionFind.TryFind(i)
+		if root == i {
+			buf.addElement(vIndex(i))
+		} else {
+			buf.UnionFind.Union(vIndex(i), vIndex(root))
+		}
+	}
+
+	for u, initialized := range other.lastRootForUInVInitialized {
+		if initialized {
+			buf.relate(buf.UValues.FetchIndex(other.UValues.At(u)), vIndex(other.lastRootForUInV[u]))
+		}
+	}
+
+	for _, relation := range other.deferred {
+		buf.BipartiteUnionFind.Union(buf.UValues.FetchIndex(other.UValues.At(relation[0])), vIndex(relation[1]))
+	}
+}
@@ -0,0 +1,217 @@
+package unionfind_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	t.Run("UnionFind", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(0, 1)
+		uf.Union(5, 6)
+
+		other := unionfind.NewUnionFind(0)
+		other.Union(1, 2)
+		other.Union(3, 4)
+		other.Find(7)
+
+		uf.Merge(other)
+		assert.True(t, uf.Connected(0, 2))
+		assert.True(t, uf.Connected(3, 4))
+		assert.False(t, uf.Connected(2, 3))
+		assert.False(t, uf.Connected(5, 7))
+		assert.True(t, uf.Contains(7))
+		assert.Equal(t, 8, uf.RootCount)
+		assert.Equal(t, 3, uf.NonSingletonCount)
+
+		assert.False(t, other.Connected(0, 1), "other is left unchanged")
+	})
+
+	t.Run("AggregatingUnionFind", func(t *testing.T) {
+		sum := func(a, b int) int { return a + b }
+
+		uf := unionfind.NewAggregatingUnionFind(0, sum)
+		uf.Add(0, 10)
+		uf.Union(0, 1)
+		uf.Add(8, 1)
+
+		other := unionfind.NewAggregatingUnionFind(0, sum)
+		other.Add(2, 5)
+		other.Union(1, 2)
+		other.Union(3, 4)
+		other.Add(4, 7)
+
+		uf.Merge(other)
+		total, ok := uf.Aggregate(0)
+		require.True(t, ok)
+		assert.Equal(t, 15, total)
+		total, _ = uf.Aggregate(3)
+		assert.Equal(t, 7, total)
+		total, _ = uf.Aggregate(8)
+		assert.Equal(t, 1, total)
+		assert.True(t, uf.Connected(0, 2))
+		assert.Equal(t, 3, uf.RootCount())
+
+		total, _ = other.Aggregate(1)
+		assert.Equal(t, 5, total, "other is left unchanged")
+	})
+
+	t.Run("AggregatingUnionFindWithValues", func(t *testing.T) {
+		sum := func(a, b int) int { return a + b }
+
+		uf := unionfind.NewAggregatingUnionFindWithValues[string](0, sum)
+		uf.Add("alice", 10)
+		uf.Union("alice", "alice@example.com")
+
+		other := unionfind.NewAggregatingUnionFindWithValues[string](0, sum)
+		other.Add("alicia", 5)
+		other.Union("alicia", "alice@example.com")
+		other.Add("bob", 2)
+
+		uf.Merge(other)
+		total, ok := uf.Aggregate("alicia")
+		require.True(t, ok)
+		assert.Equal(t, 15, total)
+		total, ok = uf.Aggregate("bob")
+		require.True(t, ok)
+		assert.Equal(t, 2, total)
+		assert.False(t, uf.Connected("alice", "bob"))
+	})
+
+	t.Run("shards match building from every edge", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 500
+
+		whole := unionfind.NewUnionFindWithValues[int](0)
+		shards := make([]*unionfind.AlgoUnionFindWithValues[int], 4)
+		for i := range shards {
+			shards[i] = unionfind.NewUnionFindWithValues[int](0)
+		}
+
+		for range n {
+			a, b := rng.Intn(n), rng.Intn(n)
+			whole.Union(a, b)
+			shards[rng.Intn(len(shards))].Union(a, b)
+		}
+
+		merged := unionfind.NewUnionFindWithValues[int](0)
+		for _, shard := range shards {
+			merged.Merge(shard)
+		}
+
+		assert.Equal(t, whole.RootCount, merged.RootCount)
+		assert.Equal(t, whole.NonSingletonCount, merged.NonSingletonCount)
+
+		// The same sets have the same sizes and a root in one for every root in the other
+		roots := make(map[int]int)
+		for a := range n {
+			require.Equal(t, whole.Contains(a), merged.Contains(a))
+			if !whole.Contains(a) {
+				continue
+			}
+
+			assert.Equal(t, whole.SizeOf(a), merged.SizeOf(a))
+			root, ok := roots[whole.FindReturningValue(a)]
+			if !ok {
+				root = merged.FindReturningValue(a)
+				roots[whole.FindReturningValue(a)] = root
+			}
+			assert.Equal(t, root, merged.FindReturningValue(a))
+		}
+	})
+
+	t.Run("values are matched by value", func(t *testing.T) {
+		day1 := unionfind.NewUnionFindWithValues[string](0)
+		day1.Union("alice", "10.0.0.1")
+		day1.Union("bob", "10.0.0.2")
+
+		day2 := unionfind.NewUnionFindWithValues[string](0)
+		day2.Union("10.0.0.2", "mallory")
+		day2.Union("mallory", "alice")
+		day2.Find("carol")
+
+		day1.Merge(day2)
+		assert.True(t, day1.Connected("10.0.0.1", "bob"))
+		assert.True(t, day1.Contains("carol"))
+		assert.Equal(t, 5, day1.SizeOf("alice"))
+	})
+
+	t.Run("removed values are not merged", func(t *testing.T) {
+		other := unionfind.NewUnionFindWithValues[string](0)
+		other.EnableEdgeRetention()
+		other.Union("a", "b")
+		other.Union("b", "c")
+		require.True(t, other.Remove("b"))
+
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Merge(other)
+		assert.False(t, uf.Contains("b"))
+		assert.False(t, uf.Connected("a", "c"))
+		assert.True(t, uf.Contains("a"))
+	})
+
+	t.Run("bipartite", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.Union("alice", "chess club")
+		buf.Union("bob", "tennis club")
+
+		other := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		other.Union("carol", "golf club")
+		other.Union("carol", "tennis club")
+		other.Union("alice", "book club")
+		other.Union("dave", "squash club")
+
+		buf.Merge(other)
+		assertConnected := func(u1, u2 string, connected bool) {
+			t.Helper()
+			root1, ok1 := buf.FindVRootForU(u1)
+			root2, ok2 := buf.FindVRootForU(u2)
+			require.True(t, ok1 && ok2)
+			assert.Equal(t, connected, root1 == root2, "%s and %s", u1, u2)
+		}
+
+		assertConnected("bob", "carol", true)
+		assertConnected("alice", "carol", false)
+		assertConnected("alice", "dave", false)
+		assert.True(t, buf.VsConnected("chess club", "book club"), "alice bridges both clubs")
+		assert.True(t, buf.VsConnected("golf club", "tennis club"))
+	})
+
+	t.Run("bipartite keeps suppressed associations from bridging", func(t *testing.T) {
+		other := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		other.SetMaxVDegree(1)
+		other.UnionAll([]unionfind.Relation[string, string]{
+			{U: "alice", V: "gmail.com"},
+			{U: "bob", V: "gmail.com"},
+		}, 1)
+
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.Union("alice", "chess club")
+		buf.Merge(other)
+
+		_, ok := buf.FindVRootForU("bob")
+		assert.False(t, ok)
+		assert.False(t, buf.VsConnected("chess club", "gmail.com"))
+	})
+
+	t.Run("bipartite relates deferred relations", func(t *testing.T) {
+		other := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		other.SetMaxVDegree(2)
+		other.Union("alice", "chess club")
+		other.Union("alice", "book club")
+
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.Merge(other)
+
+		assert.Equal(t, 2, other.Deferred())
+		assert.True(t, buf.VsConnected("chess club", "book club"))
+	})
+}
@@ -0,0 +1,260 @@
+package unionfind
+
+// Provenance mode records the edges of the spanning forest, the unions that
+// actually merged two sets, so the chain of input edges connecting any two
+// members of a set can be explained. Edges that didn't merge anything are
+// redundant and aren't recorded, which keeps the forest to one edge per merge.
+
+// provenance is a spanning forest of recorded input edges
+type provenance struct {
+	// Input edges in the order they were recorded
+	edges [][2]int
+	// Forest nodes joined by each edge, the same as the edge
+	// for UnionFind but distinguishing U from V nodes for BipartiteUnionFind
+	nodes [][2]int
+	// Indices of the edges touching each node
+	adjacent map[int][]int
+}
+
+func newProvenance() *provenance {
+	return &provenance{adjacent: make(map[int][]int)}
+}
+
+func (p *provenance) record(edge [2]int, nodeA, nodeB int) {
+	i := len(p.edges)
+	p.edges = append(p.edges, edge)
+	p.nodes = append(p.nodes, [2]int{nodeA, nodeB})
+	p.adjacent[nodeA] = append(p.adjacent[nodeA], i)
+	p.adjacent[nodeB] = append(p.adjacent[nodeB], i)
+}
+
+// truncate forgets every edge recorded after the first n
+func (p *provenance) truncate(n int) {
+	for len(p.edges) > n {
+		last := len(p.edges) - 1
+		for _, node := range p.nodes[last] {
+			adjacent := p.adjacent[node]
+			if len(adjacent) == 1 {
+				delete(p.adjacent, node)
+			} else {
+				p.adjacent[node] = adjacent[:len(adjacent)-1]
+			}
+		}
+
+		p.edges = p.edges[:last]
+		p.nodes = p.nodes[:last]
+	}
+}
+
+// forget forgets every edge touching any of the given nodes
+func (p *provenance) forget(nodes []int) {
+	touched := false
+	for _, node := range nodes {
+		if len(p.adjacent[node]) > 0 {
+			touched = true
+			break
+		}
+	}
+	if !touched {
+		return
+	}
+
+	forgotten := make(map[int]bool, len(nodes))
+	for _, node := range nodes {
+		forgotten[node] = true
+	}
+
+	edges, nodePairs := p.edges, p.nodes
+	p.edges, p.nodes = nil, nil
+	p.adjacent = make(map[int][]int)
+	for i, edge := range edges {
+		if !forgotten[nodePairs[i][0]] && !forgotten[nodePairs[i][1]] {
+			p.record(edge, nodePairs[i][0], nodePairs[i][1])
+		}
+	}
+}
+
+// path returns the edges on the path from node from to node to,
+// and false if they aren't in the same tree of the forest
+func (p *provenance) path(from, to int) ([][2]int, bool) {
+	if from == to {
+		return [][2]int{}, true
+	}
+
+	// Edge used to reach each node from from, -1 for from itself
+	via := map[int]int{from: -1}
+	queue := []int{from}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, i := range p.adjacent[node] {
+			next := p.nodes[i][0]
+			if next == node {
+				next = p.nodes[i][1]
+			}
+			if _, seen := via[next]; seen {
+				continue
+			}
+
+			via[next] = i
+			if next == to {
+				return p.walkBack(via, from, to), true
+			}
+			queue = append(queue, next)
+		}
+	}
+
+	return nil, false
+}
+
+func (p *provenance) walkBack(via map[int]int, from, to int) [][2]int {
+	var path [][2]int
+	for node := to; node != from; {
+		i := via[node]
+		path = append(path, p.edges[i])
+		if p.nodes[i][0] == node {
+			node = p.nodes[i][1]
+		} else {
+			node = p.nodes[i][0]
+		}
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
+
+// EnableProvenance starts recording the unions that merge sets so Explain
+// can tell why two elements are connected. Only unions made from now on are recorded,
+// so it should be enabled before the first union. Unions are made one at a time
+// by UnionAll while it's enabled.
+func (uf *UnionFind) EnableProvenance() {
+	if uf.provenance == nil {
+		uf.provenance = newProvenance()
+	}
+}
+
+// DisableProvenance stops recording unions and forgets the ones recorded
+func (uf *UnionFind) DisableProvenance() {
+	uf.provenance = nil
+}
+
+// Explain returns the chain of edges passed to Union that connects a to b,
+// each as it was passed, and false if they aren't connected
+// or provenance wasn't enabled when they were.
+// Elements that have not been added are not added by the call.
+func (uf *UnionFind) Explain(a, b int) ([][2]int, bool) {
+	if uf.provenance == nil || !uf.Connected(a, b) {
+		return nil, false
+	}
+
+	return uf.provenance.path(a, b)
+}
+
+// Explain returns the chain of pairs passed to Union that connects values a and b,
+// and false if they aren't connected or provenance wasn't enabled when they were.
+// Values that have not been added are not added by the call.
+func (uf *AlgoUnionFindWithValues[T]) Explain(a, b T) ([][2]T, bool) {
+	indexA, okA := uf.values.Lookup(a)
+	indexB, okB := uf.values.Lookup(b)
+	if !okA || !okB {
+		return nil, false
+	}
+
+	path, ok := uf.UnionFind.Explain(indexA, indexB)
+	if !ok {
+		return nil, false
+	}
+
+	pairs := make([][2]T, len(path))
+	for i, edge := range path {
+		pairs[i] = [2]T{uf.values.At(edge[0]), uf.values.At(edge[1])}
+	}
+
+	return pairs, true
+}
+
+// Nodes of the bipartite forest interleave U and V indices
+func uNode(u int) int { return 2*u + 1 }
+func vNode(v int) int { return 2 * v }
+
+// Explain returns the chain of (u, v) relations passed to Union
+// that connects the V elements v1 and v2, and false if they aren't connected
+// or provenance wasn't enabled when they were.
+// Elements that have not been added are not added by the call.
+func (buf *BipartiteUnionFind) Explain(v1, v2 int) ([][2]int, bool) {
+	if buf.provenance == nil || !buf.Connected(v1, v2) {
+		return nil, false
+	}
+
+	return buf.provenance.path(vNode(v1), vNode(v2))
+}
+
+// ExplainUs returns the chain of (u, v) relations passed to Union
+// that connects the U elements u1 and u2, and false if they aren't connected
+// or provenance wasn't enabled when they were.
+func (buf *BipartiteUnionFind) ExplainUs(u1, u2 int) ([][2]int, bool) {
+	root1, ok1 := buf.TryFindAssociatedRoot(u1)
+	root2, ok2 := buf.TryFindAssociatedRoot(u2)
+	if buf.provenance == nil || !ok1 || !ok2 || root1 != root2 {
+		return nil, false
+	}
+
+	return buf.provenance.path(uNode(u1), uNode(u2))
+}
+
+// ExplainUs returns the chain of relations passed to Union that connects
+// the U values u1 and u2, and false if they aren't connected
+// or provenance wasn't enabled when they were.
+// Values that have not been added are not added by the call.
+func (buf *BipartiteUnionFindWithValues[U, V]) ExplainUs(u1, u2 U) ([]Relation[U, V], bool) {
+	index1, ok1 := buf.UValues.Lookup(u1)
+	index2, ok2 := buf.UValues.Lookup(u2)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+
+	path, ok := buf.BipartiteUnionFind.ExplainUs(index1, index2)
+	if !ok {
+		return nil, false
+	}
+
+	return buf.relations(path), true
+}
+
+// Explain returns the chain of relations passed to Union that connects
+// the V values v1 and v2, and false if they aren't connected
+// or provenance wasn't enabled when they were.
+// It shadows BipartiteUnionFind.Explain, which takes V indices rather than values.
+// Values that have not been added are not added by the call.
+func (buf *BipartiteUnionFindWithValues[U, V]) Explain(v1, v2 V) ([]Relation[U, V], bool) {
+	index1, ok1 := buf.VValues.Lookup(v1)
+	index2, ok2 := buf.VValues.Lookup(v2)
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+
+	path, ok := buf.BipartiteUnionFind.Explain(index1, index2)
+	if !ok {
+		return nil, false
+	}
+
+	return buf.relations(path), true
+}
+
+// ExplainVs is like Explain, to go with ExplainUs
+func (buf *BipartiteUnionFindWithValues[U, V]) ExplainVs(v1, v2 V) ([]Relation[U, V], bool) {
+	return buf.Explain(v1, v2)
+}
+
+func (buf *BipartiteUnionFindWithValues[U, V]) relations(edges [][2]int) []Relation[U, V] {
+	relations := make([]Relation[U, V], len(edges))
+	for i, edge := range edges {
+		relations[i] = Relation[U, V]{U: buf.UValues.At(edge[0]), V: buf.VValues.At(edge[1])}
+	}
+
+	return relations
+}
@@ -0,0 +1,192 @@
+package unionfind_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Explain returns the chain of input edges", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.EnableProvenance()
+		uf.Union("alice", "alice@example.com")
+		uf.Union("alice@example.com", "555-0100")
+		uf.Union("mallory", "10.0.0.1")
+		uf.Union("555-0100", "alice") // redundant, not part of the forest
+		uf.Union("10.0.0.1", "555-0100")
+		uf.Union("bob", "carol")
+
+		path, ok := uf.Explain("alice", "mallory")
+		require.True(t, ok)
+		assert.Equal(t, [][2]string{
+			{"alice", "alice@example.com"},
+			{"alice@example.com", "555-0100"},
+			{"10.0.0.1", "555-0100"},
+			{"mallory", "10.0.0.1"},
+		}, path)
+
+		path, ok = uf.Explain("alice", "alice")
+		require.True(t, ok)
+		assert.Empty(t, path)
+
+		_, ok = uf.Explain("alice", "bob")
+		assert.False(t, ok)
+		_, ok = uf.Explain("alice", "dave")
+		assert.False(t, ok)
+		assert.False(t, uf.Contains("dave"))
+	})
+
+	t.Run("Explain requires provenance", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(0, 1)
+		_, ok := uf.Explain(0, 1)
+		assert.False(t, ok)
+
+		uf.EnableProvenance()
+		uf.Union(1, 2)
+		_, ok = uf.Explain(1, 2)
+		assert.True(t, ok)
+		_, ok = uf.Explain(0, 2)
+		assert.False(t, ok, "the union of 0 and 1 was never recorded")
+
+		uf.DisableProvenance()
+		_, ok = uf.Explain(1, 2)
+		assert.False(t, ok)
+	})
+
+	t.Run("paths are valid chains", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 200
+
+		uf := unionfind.NewUnionFind(0)
+		uf.EnableProvenance()
+		edges := make([][2]int, 3*n/2)
+		for i := range edges {
+			edges[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+		}
+		uf.UnionAll(edges, 4)
+
+		inputs := make(map[[2]int]bool)
+		for _, edge := range edges {
+			inputs[edge] = true
+		}
+
+		for range n {
+			a, b := rng.Intn(n), rng.Intn(n)
+			path, ok := uf.Explain(a, b)
+			require.Equal(t, uf.Connected(a, b), ok)
+			if !ok {
+				continue
+			}
+
+			at := a
+			for _, edge := range path {
+				assert.True(t, inputs[edge])
+				require.Contains(t, edge, at)
+				if edge[0] == at {
+					at = edge[1]
+				} else {
+					at = edge[0]
+				}
+			}
+			assert.Equal(t, b, at)
+		}
+	})
+
+	t.Run("rollback forgets recorded edges", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.EnableProvenance()
+		uf.Union(0, 1)
+
+		checkpoint := uf.Checkpoint()
+		uf.Union(1, 2)
+		require.NoError(t, uf.Rollback(checkpoint))
+		uf.Union(2, 3)
+		uf.Union(3, 0)
+
+		path, ok := uf.Explain(1, 2)
+		require.True(t, ok)
+		assert.Equal(t, [][2]int{{0, 1}, {3, 0}, {2, 3}}, path)
+	})
+
+	t.Run("encoding keeps recorded edges", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.EnableProvenance()
+		uf.Union(0, 1)
+		uf.Union(2, 1)
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.UnionFind
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, uf, &restored)
+
+		path, ok := restored.Explain(0, 2)
+		require.True(t, ok)
+		assert.Equal(t, [][2]int{{0, 1}, {2, 1}}, path)
+	})
+
+	t.Run("bipartite", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.EnableProvenance()
+		buf.Union("alice", "chess club")
+		buf.Union("alice", "tennis club")
+		buf.Union("bob", "tennis club")
+		buf.Union("bob", "chess club") // redundant
+		buf.Union("mallory", "golf club")
+		buf.Union("mallory", "chess club")
+		buf.Union("carol", "book club")
+
+		path, ok := buf.ExplainUs("bob", "mallory")
+		require.True(t, ok)
+		assert.Equal(t, []unionfind.Relation[string, string]{
+			{U: "bob", V: "tennis club"},
+			{U: "alice", V: "tennis club"},
+			{U: "alice", V: "chess club"},
+			{U: "mallory", V: "chess club"},
+		}, path)
+
+		path, ok = buf.ExplainVs("tennis club", "golf club")
+		require.True(t, ok)
+		assert.Equal(t, []unionfind.Relation[string, string]{
+			{U: "alice", V: "tennis club"},
+			{U: "alice", V: "chess club"},
+			{U: "mallory", V: "chess club"},
+			{U: "mallory", V: "golf club"},
+		}, path)
+
+		explained, ok := buf.Explain("tennis club", "golf club")
+		require.True(t, ok)
+		assert.Equal(t, path, explained)
+
+		_, ok = buf.ExplainUs("alice", "carol")
+		assert.False(t, ok)
+		_, ok = buf.ExplainVs("chess club", "squash club")
+		assert.False(t, ok)
+		_, ok = buf.Explain("chess club", "book club")
+		assert.False(t, ok)
+		assert.False(t, buf.ContainsV("squash club"))
+	})
+
+	t.Run("constrained", func(t *testing.T) {
+		uf := unionfind.NewConstrainedUnionFindWithValues[string](0)
+		uf.EnableProvenance()
+		uf.CannotLink("a", "d")
+		uf.Union("a", "b")
+		uf.Union("c", "d")
+		uf.Union("b", "c")
+		uf.Union("b", "e")
+
+		path, ok := uf.Explain("a", "e")
+		require.True(t, ok)
+		assert.Equal(t, [][2]string{{"a", "b"}, {"b", "e"}}, path)
+	})
+}
@@ -0,0 +1,264 @@
+package unionfind
+
+import (
+	"maps"
+	"slices"
+)
+
+// Edge retention keeps every edge passed to Union so edges and elements can be
+// removed again. Parent pointers alone can't tell which edges held a set together,
+// so a removal searches the retained edges of the affected set for its
+// remaining components and regroups each of them into a flat set,
+// costing time proportional to the size of the set rather than the structure.
+
+// retainedEdges is a multigraph of the edges passed to Union
+type retainedEdges struct {
+	// Number of times each edge was passed, as it was passed
+	counts map[[2]int]int
+	// Number of edges between each pair of elements in either direction
+	neighbors map[int]map[int]int
+}
+
+func newRetainedEdges() *retainedEdges {
+	return &retainedEdges{
+		counts:    make(map[[2]int]int),
+		neighbors: make(map[int]map[int]int),
+	}
+}
+
+func (r *retainedEdges) add(a, b int, count int) {
+	if a == b {
+		return
+	}
+
+	r.counts[[2]int{a, b}] += count
+	r.link(a, b, count)
+	r.link(b, a, count)
+}
+
+func (r *retainedEdges) link(a, b int, count int) {
+	if r.neighbors[a] == nil {
+		r.neighbors[a] = make(map[int]int)
+	}
+
+	r.neighbors[a][b] += count
+	if r.neighbors[a][b] <= 0 {
+		delete(r.neighbors[a], b)
+		if len(r.neighbors[a]) == 0 {
+			delete(r.neighbors, a)
+		}
+	}
+}
+
+// remove removes one copy of the edge between a and b in either direction,
+// and reports whether there was one
+func (r *retainedEdges) remove(a, b int) bool {
+	edge := [2]int{a, b}
+	if r.counts[edge] == 0 {
+		edge = [2]int{b, a}
+	}
+	if r.counts[edge] == 0 {
+		return false
+	}
+
+	r.counts[edge]--
+	if r.counts[edge] == 0 {
+		delete(r.counts, edge)
+	}
+	r.link(a, b, -1)
+	r.link(b, a, -1)
+	return true
+}
+
+// removeElement removes every edge touching n
+func (r *retainedEdges) removeElement(n int) []int {
+	var neighbors []int
+	for neighbor := range r.neighbors[n] {
+		neighbors = append(neighbors, neighbor)
+		delete(r.counts, [2]int{n, neighbor})
+		delete(r.counts, [2]int{neighbor, n})
+		delete(r.neighbors[neighbor], n)
+		if len(r.neighbors[neighbor]) == 0 {
+			delete(r.neighbors, neighbor)
+		}
+	}
+	delete(r.neighbors, n)
+	slices.Sort(neighbors)
+
+	return neighbors
+}
+
+// component returns the elements reachable from start over retained edges,
+// in the order they were reached, and the edges of a spanning tree over them
+// as they were passed to Union
+func (r *retainedEdges) component(start int) ([]int, [][2]int) {
+	members := []int{start}
+	seen := map[int]bool{start: true}
+	var tree [][2]int
+
+	for i := 0; i < len(members); i++ {
+		node := members[i]
+		for _, next := range slices.Sorted(maps.Keys(r.neighbors[node])) {
+			if seen[next] {
+				continue
+			}
+
+			seen[next] = true
+			members = append(members, next)
+			if r.counts[[2]int{node, next}] > 0 {
+				tree = append(tree, [2]int{node, next})
+			} else {
+				tree = append(tree, [2]int{next, node})
+			}
+		}
+	}
+
+	return members, tree
+}
+
+// EnableEdgeRetention starts keeping every edge passed to Union
+// so that RemoveEdge and RemoveElement can split sets again.
+// Only edges passed from now on are kept, so it should be enabled before the first union;
+// a removal regroups the affected set from the retained edges alone.
+func (uf *UnionFind) EnableEdgeRetention() {
+	if uf.retained == nil {
+		uf.retained = newRetainedEdges()
+	}
+}
+
+// RemoveEdge removes one copy of the edge between a and b, as passed to Union in either order,
+// splitting their set if nothing else connects them. It returns false if there's no such edge
+// or edge retention isn't enabled.
+// Removals can't be rolled back, so checkpoints taken before one become stale.
+func (uf *UnionFind) RemoveEdge(a, b int) bool {
+	if uf.retained == nil || !uf.retained.remove(a, b) {
+		return false
+	}
+
+	members, tree := uf.retained.component(a)
+	if slices.Contains(members, b) {
+		// The edge may have been part of the forest
+		uf.explain(members, tree)
+		return true
+	}
+
+	uf.dissolve(uf.findRoot(a))
+	uf.regroup(members, tree)
+	uf.regroup(uf.retained.component(b))
+	return true
+}
+
+// RemoveElement removes the element at index along with every edge touching it,
+// splitting its set into the parts that are still connected.
+// It returns false if the element has not been added or edge retention isn't enabled.
+// Under FirstSeenRoot it also renumbers the order every element was added in.
+// Removals can't be rolled back, so checkpoints taken before one become stale.
+func (uf *UnionFind) RemoveElement(index int) bool {
+	if uf.retained == nil || !uf.Contains(index) {
+		return false
+	}
+
+	uf.dissolve(uf.findRoot(index))
+	uf.Root[index] = index
+	uf.Rank[index] = 1
+	uf.Initialized[index] = false
+	uf.RootCount--
+	if uf.addedAt != nil {
+		// Keep the order elements were added in dense so elements added later follow the rest
+		for i, added := range uf.addedAt {
+			if uf.Initialized[i] && added > uf.addedAt[index] {
+				uf.addedAt[i]--
+			}
+		}
+	}
+	if uf.provenance != nil {
+		uf.provenance.forget([]int{index})
+	}
+
+	regrouped := make(map[int]bool)
+	for _, neighbor := range uf.retained.removeElement(index) {
+		if regrouped[neighbor] {
+			continue
+		}
+
+		members, tree := uf.retained.component(neighbor)
+		for _, member := range members {
+			regrouped[member] = true
+		}
+		uf.regroup(members, tree)
+	}
+
+	uf.rejected = slices.DeleteFunc(uf.rejected, func(edge [2]int) bool {
+		return edge[0] == index || edge[1] == index
+	})
+	return true
+}
+
+// dissolve forgets the set rooted at root ahead of its members being regrouped
+func (uf *UnionFind) dissolve(root int) {
+	if uf.Rank[root] > 1 {
+		uf.NonSingletonCount--
+	}
+	if uf.rollback {
+		uf.discardChanges()
+	}
+}
+
+// regroup makes members a single flat set rooted at its first member,
+// recording tree as the unions that merged it
+func (uf *UnionFind) regroup(members []int, tree [][2]int) {
+	root := members[0]
+	representative := root
+	for _, member := range members {
+		uf.Root[member] = root
+		representative = uf.preferredOf(representative, member)
+	}
+
+	uf.Rank[root] = len(members)
+	if len(members) > 1 {
+		uf.NonSingletonCount++
+	}
+	if uf.representative != nil {
+		uf.representative[root] = representative
+	}
+
+	uf.explain(members, tree)
+}
+
+// explain replaces the recorded unions of members with tree when provenance is enabled
+func (uf *UnionFind) explain(members []int, tree [][2]int) {
+	if uf.provenance == nil {
+		return
+	}
+
+	uf.provenance.forget(members)
+	for _, edge := range tree {
+		uf.provenance.record(edge, edge[0], edge[1])
+	}
+}
+
+// Remove removes value along with every pair containing it,
+// splitting its set into the parts that are still connected and freeing the value.
+// It returns false if the value has not been added or edge retention isn't enabled.
+func (uf *AlgoUnionFindWithValues[T]) Remove(value T) bool {
+	index, ok := uf.values.Lookup(value)
+	if !ok || !uf.RemoveElement(index) {
+		return false
+	}
+
+	uf.values.Remove(value)
+	return true
+}
+
+// RemoveEdge removes one copy of the pair of values a and b, as passed to Union in either order,
+// splitting their set if nothing else connects them. It returns false if there's no such pair
+// or edge retention isn't enabled.
+func (uf *AlgoUnionFindWithValues[T]) RemoveEdge(a, b T) bool {
+	indexA, okA := uf.values.Lookup(a)
+	indexB, okB := uf.values.Lookup(b)
+	if !okA || !okB {
+		return false
+	}
+
+	return uf.UnionFind.RemoveEdge(indexA, indexB)
+}
@@ -0,0 +1,213 @@
+package unionfind_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoval(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RemoveEdge splits a set", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.EnableEdgeRetention()
+		uf.Union(0, 1)
+		uf.Union(1, 2)
+		uf.Union(2, 3)
+		uf.Union(4, 5)
+
+		require.True(t, uf.RemoveEdge(2, 1), "edges can be removed in either order")
+		assert.True(t, uf.Connected(0, 1))
+		assert.True(t, uf.Connected(2, 3))
+		assert.False(t, uf.Connected(1, 2))
+		assert.Equal(t, 2, uf.Size(0))
+		assert.Equal(t, 2, uf.Size(3))
+		assert.Equal(t, 3, uf.NonSingletonCount)
+
+		assert.False(t, uf.RemoveEdge(1, 2), "the edge is gone")
+		assert.False(t, uf.RemoveEdge(0, 4))
+	})
+
+	t.Run("RemoveEdge keeps sets connected some other way", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.EnableEdgeRetention()
+		uf.Union(0, 1)
+		uf.Union(1, 2)
+		uf.Union(2, 0)
+		uf.Union(2, 3)
+		uf.Union(3, 2)
+
+		require.True(t, uf.RemoveEdge(0, 1))
+		assert.True(t, uf.Connected(0, 1), "connected through 2")
+		require.True(t, uf.RemoveEdge(2, 3))
+		assert.True(t, uf.Connected(2, 3), "the edge was passed twice")
+		require.True(t, uf.RemoveEdge(2, 3))
+		assert.False(t, uf.Connected(2, 3))
+		assert.Equal(t, 3, uf.Size(0))
+		assert.Equal(t, 1, uf.NonSingletonCount)
+	})
+
+	t.Run("RemoveElement splits a set into its remaining parts", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.EnableEdgeRetention()
+		uf.UnionAll([][2]int{{0, 1}, {0, 2}, {2, 3}, {0, 4}, {5, 6}}, 2)
+		rootCount := uf.RootCount
+
+		require.True(t, uf.RemoveElement(0))
+		assert.False(t, uf.Contains(0))
+		assert.Equal(t, rootCount-1, uf.RootCount)
+		assert.True(t, uf.Connected(2, 3))
+		assert.False(t, uf.Connected(1, 2))
+		assert.False(t, uf.Connected(2, 4))
+		assert.Equal(t, 1, uf.Size(1))
+		assert.Equal(t, 2, uf.Size(2))
+		assert.Equal(t, 2, uf.NonSingletonCount)
+
+		assert.False(t, uf.RemoveElement(0))
+		uf.Union(0, 1)
+		assert.True(t, uf.Connected(0, 1), "removed elements can be added again")
+		assert.False(t, uf.Connected(0, 2))
+	})
+
+	t.Run("requires edge retention", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(0, 1)
+		assert.False(t, uf.RemoveEdge(0, 1))
+		assert.False(t, uf.RemoveElement(0))
+		assert.True(t, uf.Connected(0, 1))
+	})
+
+	t.Run("root policy picks representatives of the split sets", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.SetRootPolicy(unionfind.FirstSeenRoot)
+		uf.EnableEdgeRetention()
+		uf.Union(3, 4)
+		uf.Union(1, 2)
+		uf.Union(4, 2)
+		uf.Union(5, 1)
+
+		require.True(t, uf.RemoveElement(4))
+		assert.Equal(t, 3, uf.Find(3))
+		assert.Equal(t, 1, uf.Find(2))
+		assert.Equal(t, 1, uf.Find(5))
+
+		uf.Union(4, 5)
+		assert.Equal(t, 1, uf.Find(4), "4 is now the last element added")
+	})
+
+	t.Run("provenance explains the remaining connections", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.EnableEdgeRetention()
+		uf.EnableProvenance()
+		uf.Union(0, 1)
+		uf.Union(1, 2)
+		uf.Union(2, 0) // redundant until 0-1 is removed
+		uf.Union(5, 2)
+
+		require.True(t, uf.RemoveEdge(0, 1))
+		path, ok := uf.Explain(0, 1)
+		require.True(t, ok)
+		assert.Equal(t, [][2]int{{2, 0}, {1, 2}}, path)
+
+		require.True(t, uf.RemoveElement(2))
+		_, ok = uf.Explain(0, 5)
+		assert.False(t, ok)
+	})
+
+	t.Run("matches a rebuild from the remaining edges", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 100
+
+		edges := make([][2]int, n)
+		for i := range edges {
+			a := rng.Intn(n)
+			edges[i] = [2]int{a, (a + 1 + rng.Intn(n-1)) % n}
+		}
+
+		uf := unionfind.NewUnionFind(0)
+		uf.EnableEdgeRetention()
+		uf.UnionAll(edges, 4)
+
+		for range n / 2 {
+			i := rng.Intn(len(edges))
+			require.True(t, uf.RemoveEdge(edges[i][0], edges[i][1]))
+			edges = append(edges[:i], edges[i+1:]...)
+		}
+
+		rebuilt := unionfind.NewUnionFind(0)
+		rebuilt.UnionAll(edges, 1)
+		for a := range n {
+			if !rebuilt.Contains(a) {
+				continue
+			}
+			for b := range n {
+				if rebuilt.Contains(b) {
+					assert.Equal(t, rebuilt.Connected(a, b), uf.Connected(a, b), "%d and %d", a, b)
+				}
+			}
+			assert.Equal(t, rebuilt.Size(a), uf.Size(a))
+		}
+		assert.Equal(t, rebuilt.NonSingletonCount, uf.NonSingletonCount)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.EnableEdgeRetention()
+		uf.Union("alice", "alice@example.com")
+		uf.Union("alice@example.com", "555-0100")
+		uf.Union("bob", "555-0100")
+
+		require.True(t, uf.RemoveEdge("555-0100", "alice@example.com"))
+		assert.False(t, uf.Connected("alice", "bob"))
+		assert.False(t, uf.RemoveEdge("alice", "carol"))
+
+		require.True(t, uf.Remove("555-0100"))
+		assert.False(t, uf.Contains("555-0100"))
+		assert.Equal(t, []string{"bob"}, uf.Members("bob"))
+		assert.False(t, uf.Remove("555-0100"))
+
+		uf.Union("bob", "carol")
+		assert.Equal(t, []string{"carol", "bob"}, uf.Members("bob"), "carol reuses the freed index")
+		assert.Equal(t, []string{"alice", "alice@example.com"}, uf.Members("alice"))
+	})
+
+	t.Run("encoding keeps retained edges", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.EnableEdgeRetention()
+		uf.Union("a", "b")
+		uf.Union("b", "c")
+		uf.Union("c", "d")
+		uf.Union("b", "c")
+		require.True(t, uf.Remove("a"))
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		restored := unionfind.NewUnionFindWithValues[string](0)
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, uf, restored)
+
+		require.True(t, restored.RemoveEdge("c", "b"))
+		assert.True(t, restored.Connected("b", "d"))
+		require.True(t, restored.RemoveEdge("c", "b"))
+		assert.False(t, restored.Connected("b", "d"))
+
+		restored.Union("d", "e")
+		assert.Equal(t, []string{"e", "c", "d"}, restored.Members("d"), "e reuses the freed index")
+	})
+
+	t.Run("structures with state that can't be split", func(t *testing.T) {
+		bipartite := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		bipartite.EnableEdgeRetention()
+		bipartite.Union("alice", "alice@example.com")
+		bipartite.Union("alice", "10.0.0.1")
+		assert.False(t, bipartite.RemoveEdge(0, 1))
+		assert.False(t, bipartite.RemoveElement(0))
+		assert.True(t, bipartite.VsConnected("alice@example.com", "10.0.0.1"))
+	})
+}
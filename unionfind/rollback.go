@@ -0,0 +1,182 @@
+package unionfind
+
+import "errors"
+
+// Rollback mode records every change made to a UnionFind so it can be undone.
+// Path compression is turned off while it's enabled, leaving union by rank
+// to keep trees at logarithmic height, so that every union changes
+// a constant number of entries and undoing it costs O(1).
+
+var (
+	// ErrStaleCheckpoint is returned when rolling back to a checkpoint whose state can no longer
+	// be restored, because an earlier checkpoint was rolled back to, the recorded changes
+	// were discarded or an edge or element was removed since it was taken
+	ErrStaleCheckpoint = errors.New("unionfind: stale checkpoint")
+	// ErrRollbackNotSupported is returned by Rollback on structures that keep state
+	// alongside their sets which can't be rolled back
+	ErrRollbackNotSupported = errors.New("unionfind: rollback not supported")
+)
+
+// change records an element being added, when parent is -1,
+// an edge from index to parent being retained, when retained is set,
+// or the root at index being linked under parent
+// along with the member parent reported as its root before
+type change struct {
+	index          int
+	parent         int
+	representative int
+	retained       bool
+	// Number of changes recorded before this one since the structure was created,
+	// which tells apart changes recorded at the same position before and after a rollback
+	seq int
+	// Size of the set rooted at index when it was linked,
+	// kept for CompactUnionFind whose roots lose their size once linked
+	size int
+}
+
+// changeLog records the changes made to a structure in rollback mode
+type changeLog struct {
+	// Whether changes are being recorded so they can be rolled back
+	rollback bool
+	changes  []change
+	// Number of changes ever recorded, numbering each change
+	recorded int
+	// Number of times the recorded changes were discarded, making older checkpoints stale
+	generation int
+}
+
+// Checkpoint identifies a state that Rollback can return to
+type Checkpoint struct {
+	changes    int
+	seq        int
+	generation int
+	values     int
+	rejected   int
+	provenance int
+}
+
+// EnableRollback switches to rollback mode, turning off path compression
+// and recording changes so they can be undone with Checkpoint and Rollback
+func (uf *UnionFind) EnableRollback() {
+	uf.rollback = true
+}
+
+// DisableRollback leaves rollback mode, discarding the recorded changes
+// and turning path compression back on. Existing checkpoints become stale.
+func (uf *UnionFind) DisableRollback() {
+	uf.rollback = false
+	uf.discardChanges()
+}
+
+// discardChanges forgets the recorded changes, making every checkpoint stale
+func (l *changeLog) discardChanges() {
+	l.changes = nil
+	l.generation++
+}
+
+func (l *changeLog) record(c change) {
+	if l.rollback {
+		c.seq = l.recorded
+		l.recorded++
+		l.changes = append(l.changes, c)
+	}
+}
+
+// checkpoint returns a checkpoint of the recorded changes,
+// given the number of rejected edges and the recorded unions
+func (l *changeLog) checkpoint(rejected int, forest *provenance) Checkpoint {
+	checkpoint := Checkpoint{
+		changes:    len(l.changes),
+		seq:        -1,
+		generation: l.generation,
+		rejected:   rejected,
+	}
+	if len(l.changes) > 0 {
+		checkpoint.seq = l.changes[len(l.changes)-1].seq
+	}
+	if forest != nil {
+		checkpoint.provenance = len(forest.edges)
+	}
+
+	return checkpoint
+}
+
+// checkCheckpoint returns ErrStaleCheckpoint unless the state at checkpoint can be restored,
+// which is when the change recorded last before it is still the one at its position
+func (l *changeLog) checkCheckpoint(checkpoint Checkpoint) error {
+	if checkpoint.generation != l.generation || checkpoint.changes > len(l.changes) ||
+		(checkpoint.changes > 0 && l.changes[checkpoint.changes-1].seq != checkpoint.seq) {
+		return ErrStaleCheckpoint
+	}
+
+	return nil
+}
+
+// Checkpoint returns a checkpoint of the current state for Rollback,
+// switching to rollback mode if it isn't enabled yet
+func (uf *UnionFind) Checkpoint() Checkpoint {
+	uf.EnableRollback()
+	return uf.checkpoint(len(uf.rejected), uf.provenance)
+}
+
+// Rollback restores the exact state at the given checkpoint,
+// undoing every union and removing every element added since,
+// along with the edges rejected, recorded for provenance or retained since.
+// Checkpoints taken after the given checkpoint become stale.
+// It returns ErrStaleCheckpoint without changing anything if the checkpoint is stale.
+func (uf *UnionFind) Rollback(checkpoint Checkpoint) error {
+	if err := uf.checkCheckpoint(checkpoint); err != nil {
+		return err
+	}
+
+	uf.rollbackTo(checkpoint)
+	return nil
+}
+
+// rollbackTo restores the state at a checkpoint that isn't stale
+func (uf *UnionFind) rollbackTo(checkpoint Checkpoint) {
+	uf.rejected = uf.rejected[:min(checkpoint.rejected, len(uf.rejected))]
+	if uf.provenance != nil {
+		uf.provenance.truncate(checkpoint.provenance)
+	}
+
+	for len(uf.changes) > checkpoint.changes {
+		c := uf.changes[len(uf.changes)-1]
+		uf.changes = uf.changes[:len(uf.changes)-1]
+
+		if c.retained {
+			uf.retained.remove(c.index, c.parent)
+			continue
+		}
+
+		if c.parent < 0 {
+			uf.Initialized[c.index] = false
+			uf.RootCount--
+			continue
+		}
+
+		uf.Root[c.index] = c.index
+		uf.Rank[c.parent] -= uf.Rank[c.index]
+		if uf.representative != nil {
+			uf.representative[c.parent] = c.representative
+		}
+
+		uf.NonSingletonCount--
+		if uf.Rank[c.index] > 1 {
+			uf.NonSingletonCount++
+		}
+		if uf.Rank[c.parent] > 1 {
+			uf.NonSingletonCount++
+		}
+	}
+}
+
+// retain adds the edge between a and b to the retained edges when edge retention is enabled
+func (uf *UnionFind) retain(a, b int) {
+	if uf.retained == nil || a == b {
+		return
+	}
+
+	uf.retained.add(a, b, 1)
+	uf.record(change{index: a, parent: b, retained: true})
+}
@@ -0,0 +1,145 @@
+package unionfind_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("UnionFind", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		uf.Union(3, 4)
+		uf.Union(5, 6)
+
+		checkpoint := uf.Checkpoint()
+		root, rank, initialized := slices.Clone(uf.Root), slices.Clone(uf.Rank), slices.Clone(uf.Initialized)
+		rootCount, nonSingletonCount := uf.RootCount, uf.NonSingletonCount
+
+		uf.Union(2, 4)
+		inner := uf.Checkpoint()
+		uf.Union(4, 6)
+		uf.Union(6, 10)
+		assert.True(t, uf.Connected(1, 10))
+		assert.Equal(t, 7, uf.Size(1))
+
+		require.NoError(t, uf.Rollback(inner))
+		assert.True(t, uf.Connected(1, 3))
+		assert.False(t, uf.Connected(1, 5))
+		assert.False(t, uf.Contains(10))
+		assert.Equal(t, 4, uf.Size(1))
+		assert.Equal(t, 2, uf.NonSingletonCount)
+
+		require.NoError(t, uf.Rollback(checkpoint))
+		assert.Equal(t, root, uf.Root[:len(root)])
+		assert.Equal(t, rank, uf.Rank[:len(rank)])
+		assert.Equal(t, initialized, uf.Initialized[:len(initialized)])
+		assert.Equal(t, rootCount, uf.RootCount)
+		assert.Equal(t, nonSingletonCount, uf.NonSingletonCount)
+		assert.False(t, uf.Connected(1, 3))
+
+		uf.Union(1, 3)
+		assert.True(t, uf.Connected(2, 4), "unions should continue to work after a rollback")
+	})
+
+	t.Run("AlgoUnionFindWithValues", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("alice", "bob")
+		uf.Union("charlie", "dave")
+
+		checkpoint := uf.Checkpoint()
+		uf.Union("bob", "charlie")
+		uf.Union("dave", "mallory")
+		assert.True(t, uf.Connected("alice", "mallory"))
+
+		require.NoError(t, uf.Rollback(checkpoint))
+		assert.False(t, uf.Connected("alice", "charlie"))
+		assert.False(t, uf.Contains("mallory"), "values enumerated after the checkpoint should be removed")
+		assert.Equal(t, []string{"alice", "bob"}, uf.Members("alice"))
+		assert.Equal(t, 4, uf.RootCount)
+
+		assert.Equal(t, "charlie", uf.UnionReturningValue("dave", "erin"))
+		assert.Equal(t, []string{"charlie", "dave", "erin"}, uf.Members("erin"))
+	})
+
+	t.Run("stale checkpoints", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		outer := uf.Checkpoint()
+		uf.Union(2, 3)
+		inner := uf.Checkpoint()
+
+		require.NoError(t, uf.Rollback(outer))
+		require.ErrorIs(t, uf.Rollback(inner), unionfind.ErrStaleCheckpoint)
+		uf.Union(2, 4)
+		uf.Union(4, 5)
+		require.ErrorIs(t, uf.Rollback(inner), unionfind.ErrStaleCheckpoint,
+			"changes recorded after a rollback don't revive later checkpoints")
+		assert.True(t, uf.Connected(1, 5))
+
+		require.NoError(t, uf.Rollback(outer), "checkpoints can be rolled back to more than once")
+		assert.False(t, uf.Contains(4))
+
+		uf.EnableEdgeRetention()
+		uf.Union(6, 7)
+		uf.RemoveEdge(6, 7)
+		require.ErrorIs(t, uf.Rollback(outer), unionfind.ErrStaleCheckpoint)
+
+		checkpoint := uf.Checkpoint()
+		uf.DisableRollback()
+		uf.Union(8, 9)
+		require.ErrorIs(t, uf.Rollback(checkpoint), unionfind.ErrStaleCheckpoint)
+		assert.True(t, uf.Connected(8, 9))
+	})
+
+	t.Run("retained edges", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.EnableEdgeRetention()
+		uf.Union(1, 2)
+
+		checkpoint := uf.Checkpoint()
+		uf.Union(2, 3)
+		uf.Union(1, 3)
+		require.NoError(t, uf.Rollback(checkpoint))
+
+		assert.False(t, uf.RemoveEdge(2, 3), "edges retained after the checkpoint are rolled back")
+		uf.Union(3, 4)
+		assert.True(t, uf.RemoveEdge(1, 2))
+		assert.False(t, uf.Connected(1, 3), "rolled back unions shouldn't come back when regrouping")
+		assert.True(t, uf.Connected(3, 4))
+	})
+
+	t.Run("values reusing a freed index", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.EnableEdgeRetention()
+		uf.Union("alice", "bob")
+		uf.Union("charlie", "dave")
+		uf.Remove("bob")
+
+		checkpoint := uf.Checkpoint()
+		uf.Union("erin", "alice")
+		assert.True(t, uf.Connected("erin", "alice"))
+
+		require.NoError(t, uf.Rollback(checkpoint))
+		assert.False(t, uf.Contains("erin"), "a value that reused a freed index should be removed")
+		assert.Nil(t, uf.Members("erin"))
+		assert.Equal(t, []string{"alice"}, uf.Members("alice"))
+		assert.Equal(t, 3, uf.RootCount)
+
+		uf.Union("frank", "alice")
+		assert.Equal(t, []string{"alice", "frank"}, uf.Members("frank"), "frank reuses the freed index again")
+	})
+
+	t.Run("structures with state that can't be rolled back", func(t *testing.T) {
+		bipartite := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		bipartite.Union("alice", "alice@example.com")
+		require.ErrorIs(t, bipartite.Rollback(bipartite.Checkpoint()), unionfind.ErrRollbackNotSupported)
+	})
+}
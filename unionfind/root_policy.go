@@ -0,0 +1,107 @@
+package unionfind
+
+// RootPolicy decides which member of a set is reported as its root.
+// Sets are always balanced by rank, the policy only picks
+// the member that Find, Union and friends report for the set.
+type RootPolicy int
+
+const (
+	// RankRoot reports the root of the set's tree,
+	// which depends on the order the unions were made in
+	RankRoot RootPolicy = iota
+	// MinIndexRoot reports the member with the smallest index.
+	// The value wrappers index values in the order they are first seen,
+	// so for them this is the value that was seen first.
+	MinIndexRoot
+	// FirstSeenRoot reports the member that was added first
+	FirstSeenRoot
+	// cmpRoot reports the smallest member by a comparison function given to SetRootCmp
+	cmpRoot
+)
+
+// SetRootPolicy sets which member of each set is reported as its root.
+// Elements that already exist when switching to FirstSeenRoot
+// are taken to have been added in index order.
+func (uf *UnionFind) SetRootPolicy(policy RootPolicy) {
+	uf.addedAt = nil
+	if policy == FirstSeenRoot {
+		uf.addedAt = make([]int, len(uf.Root))
+		added := 0
+		for i, initialized := range uf.Initialized {
+			if initialized {
+				uf.addedAt[i] = added
+				added++
+			}
+		}
+	}
+
+	if policy != MinIndexRoot && policy != FirstSeenRoot {
+		uf.rootPolicy = RankRoot
+		uf.prefer = nil
+		uf.representative = nil
+		return
+	}
+
+	uf.setPreference(policy, uf.preferenceFor(policy))
+}
+
+// preferenceFor returns the preference of the built in policies
+func (uf *UnionFind) preferenceFor(policy RootPolicy) func(a, b int) bool {
+	switch policy {
+	case MinIndexRoot:
+		return func(a, b int) bool { return a < b }
+	case FirstSeenRoot:
+		return func(a, b int) bool {
+			return uf.addedAt[a] < uf.addedAt[b] || (uf.addedAt[a] == uf.addedAt[b] && a < b)
+		}
+	default:
+		return nil
+	}
+}
+
+// setPreference reports the member each set prefers as its root
+// where prefer reports whether a is preferred over b
+func (uf *UnionFind) setPreference(policy RootPolicy, prefer func(a, b int) bool) {
+	uf.rootPolicy = policy
+	uf.prefer = prefer
+	uf.representative = make([]int, len(uf.Root))
+	uf.electRepresentatives()
+}
+
+// electRepresentatives picks the preferred member of every set from scratch
+func (uf *UnionFind) electRepresentatives() {
+	if uf.representative == nil {
+		return
+	}
+
+	for i, initialized := range uf.Initialized {
+		if initialized && uf.Root[i] == i {
+			uf.representative[i] = i
+		}
+	}
+	for i, initialized := range uf.Initialized {
+		if initialized {
+			root := uf.findRoot(i)
+			uf.representative[root] = uf.preferredOf(uf.representative[root], i)
+		}
+	}
+}
+
+// representativeOf returns the member reported as the root of the tree rooted at root
+func (uf *UnionFind) representativeOf(root int) int {
+	if uf.representative == nil {
+		return root
+	}
+
+	return uf.representative[root]
+}
+
+// preferredOf returns whichever of members a and b is preferred as a root,
+// a if there's no preference
+func (uf *UnionFind) preferredOf(a, b int) int {
+	if uf.prefer != nil && uf.prefer(b, a) {
+		return b
+	}
+
+	return a
+}
@@ -0,0 +1,146 @@
+package unionfind_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootPolicy(t *testing.T) {
+	t.Parallel()
+
+	edges := [][2]int{{7, 3}, {3, 9}, {12, 4}, {4, 8}, {8, 15}, {1, 20}}
+
+	t.Run("MinIndexRoot is independent of union order", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		for range 10 {
+			uf := unionfind.NewUnionFind(0)
+			uf.SetRootPolicy(unionfind.MinIndexRoot)
+			for _, i := range rng.Perm(len(edges)) {
+				a, b := edges[i][0], edges[i][1]
+				if rng.Intn(2) == 0 {
+					a, b = b, a
+				}
+				uf.Union(a, b)
+			}
+
+			assert.Equal(t, 3, uf.Find(9))
+			assert.Equal(t, 4, uf.Find(15))
+			assert.Equal(t, 1, uf.Find(20))
+			assert.Equal(t, []int{4}, uf.LargestSets(1))
+
+			root, ok := uf.TryFind(7)
+			assert.True(t, ok)
+			assert.Equal(t, 3, root)
+		}
+	})
+
+	t.Run("FirstSeenRoot", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.SetRootPolicy(unionfind.FirstSeenRoot)
+		uf.Union(5, 3)
+		uf.Union(1, 2)
+		assert.Equal(t, 1, uf.Union(2, 1))
+		assert.Equal(t, 5, uf.Union(2, 3))
+		assert.Equal(t, 5, uf.Find(1))
+	})
+
+	t.Run("switching policy with existing sets", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(9, 4)
+		uf.Union(9, 6)
+		assert.Equal(t, 9, uf.Find(6))
+
+		uf.SetRootPolicy(unionfind.MinIndexRoot)
+		assert.Equal(t, 4, uf.Find(6))
+
+		uf.SetRootPolicy(unionfind.RankRoot)
+		assert.Equal(t, 9, uf.Find(6))
+	})
+
+	t.Run("with rollback and UnionAll", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.SetRootPolicy(unionfind.MinIndexRoot)
+		uf.Union(5, 6)
+
+		checkpoint := uf.Checkpoint()
+		uf.Union(6, 2)
+		assert.Equal(t, 2, uf.Find(5))
+		require.NoError(t, uf.Rollback(checkpoint))
+		assert.Equal(t, 5, uf.Find(6))
+
+		uf.DisableRollback()
+		uf.UnionAll(edges, 3)
+		assert.Equal(t, 3, uf.Find(9))
+		assert.Equal(t, 4, uf.Find(15))
+		assert.Equal(t, 5, uf.Find(6))
+	})
+
+	t.Run("is kept by the binary encoding", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.SetRootPolicy(unionfind.FirstSeenRoot)
+		uf.Union(5, 3)
+
+		data, err := uf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.UnionFind
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, 5, restored.Union(1, 3))
+		assert.Equal(t, 5, restored.Find(1))
+	})
+
+	t.Run("AlgoUnionFindWithValues.SetRootCmp", func(t *testing.T) {
+		pairs := [][2]string{{"mallory", "bob"}, {"bob", "erin"}, {"dave", "charlie"}, {"alice", "erin"}}
+
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		for range 10 {
+			uf := unionfind.NewUnionFindWithValues[string](0)
+			uf.SetRootCmp(strings.Compare)
+			for _, i := range rng.Perm(len(pairs)) {
+				uf.Union(pairs[i][0], pairs[i][1])
+			}
+
+			assert.Equal(t, "alice", uf.FindReturningValue("mallory"))
+			assert.Equal(t, "charlie", uf.FindReturningValue("dave"))
+			assert.Equal(t, []string{"alice", "charlie"}, uf.LargestSets(2))
+		}
+	})
+
+	t.Run("BipartiteUnionFindWithValues.SetRootCmp", func(t *testing.T) {
+		uf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		uf.SetRootCmp(strings.Compare)
+		uf.Union("Stanley McFred", "Rad Corp")
+		uf.Union("Stanley McFred", "Milquetoast Inc")
+		uf.Union("Tom", "McNotDonalds")
+		uf.Union("Tom", "Milquetoast Inc")
+
+		root, ok := uf.FindVRootForU("Stanley McFred")
+		assert.True(t, ok)
+		assert.Equal(t, "McNotDonalds", root)
+		assert.Equal(t, "McNotDonalds", uf.FindReturningValue("Rad Corp"))
+
+		_, err := uf.MarshalBinary()
+		require.ErrorIs(t, err, unionfind.ErrRootCmpNotEncodable)
+	})
+
+	t.Run("SetRootCmp can't be encoded", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.SetRootCmp(strings.Compare)
+		uf.Union("z", "m")
+
+		_, err := uf.MarshalBinary()
+		require.ErrorIs(t, err, unionfind.ErrRootCmpNotEncodable)
+		_, err = uf.UnionFind.MarshalBinary()
+		require.ErrorIs(t, err, unionfind.ErrRootCmpNotEncodable)
+
+		uf.SetRootPolicy(unionfind.MinIndexRoot)
+		_, err = uf.MarshalBinary()
+		require.NoError(t, err)
+	})
+}
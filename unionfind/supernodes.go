@@ -0,0 +1,109 @@
+package unionfind
+
+// A handful of V values, such as "gmail.com" or an empty string, may be shared
+// by so many Us that they bridge everything into one set. A maximum V degree
+// stops those supernodes from bridging: relations to a V related to more than the
+// maximum number of distinct Us don't union anything, and Us that only relate to such Vs
+// aren't associated with any set. A V's degree is only known once all of its
+// relations have been seen, so while a maximum is set relations are deferred
+// until Flush, or until the end of the batch for UnionAll.
+
+// SetMaxVDegree sets the most distinct Us a V may be related to and still bridge them.
+// Repeated relations between the same U and V are only counted once.
+// Degrees are only counted while a maximum is set, and Union defers every relation
+// until Flush so that none are made through a V before it passes the limit.
+// A degree of 0 or less removes the limit, flushing the deferred relations.
+func (buf *BipartiteUnionFind) SetMaxVDegree(degree int) {
+	buf.maxVDegree = max(degree, 0)
+	if buf.maxVDegree == 0 {
+		buf.Flush()
+	}
+}
+
+// Flush makes the relations deferred by Union while there's a maximum V degree,
+// skipping the ones to Vs that are suppressed by then. Relations to a V that passes
+// the limit after they were flushed keep the unions they made.
+func (buf *BipartiteUnionFind) Flush() {
+	deferred := buf.deferred
+	buf.deferred = nil
+	buf.relateAll(deferred, 1)
+}
+
+// Deferred returns the number of relations waiting for Flush
+func (buf *BipartiteUnionFind) Deferred() int {
+	return len(buf.deferred)
+}
+
+// MaxVDegree returns the most distinct Us a V may be related to and still bridge them, 0 if there's no limit
+func (buf *BipartiteUnionFind) MaxVDegree() int {
+	return buf.maxVDegree
+}
+
+// VDegree returns the number of distinct Us related to v counted so far
+func (buf *BipartiteUnionFind) VDegree(v int) int {
+	if v < 0 || v >= len(buf.vDegree) {
+		return 0
+	}
+
+	return buf.vDegree[v]
+}
+
+// Suppressed reports whether v is related to more distinct Us than the maximum V degree
+// and so no longer bridges them
+func (buf *BipartiteUnionFind) Suppressed(v int) bool {
+	return buf.maxVDegree > 0 && buf.VDegree(v) > buf.maxVDegree
+}
+
+// SuppressedVs returns the indices of every suppressed V in ascending order
+func (buf *BipartiteUnionFind) SuppressedVs() []int {
+	var suppressed []int
+	for v := range buf.vDegree {
+		if buf.Suppressed(v) {
+			suppressed = append(suppressed, v)
+		}
+	}
+
+	return suppressed
+}
+
+// countDegree counts u towards the degree of v unless the relation was counted before
+func (buf *BipartiteUnionFind) countDegree(u, v int) {
+	if buf.maxVDegree == 0 {
+		return
+	}
+
+	relation := [2]int{u, v}
+	if _, ok := buf.counted[relation]; ok {
+		return
+	}
+	if buf.counted == nil {
+		buf.counted = make(map[[2]int]struct{})
+	}
+	buf.counted[relation] = struct{}{}
+
+	if v >= len(buf.vDegree) {
+		buf.vDegree = expandSlice(buf.vDegree, v)
+	}
+	buf.vDegree[v]++
+}
+
+// SuppressedVs returns every suppressed V value in the order they were first seen
+func (buf *BipartiteUnionFindWithValues[U, V]) SuppressedVs() []V {
+	return buf.VValues.AtEach(buf.BipartiteUnionFind.SuppressedVs())
+}
+
+// VDegreeOf returns the number of distinct U values related to the V value v counted so far
+func (buf *BipartiteUnionFindWithValues[U, V]) VDegreeOf(v V) int {
+	index, ok := buf.VValues.Lookup(v)
+	if !ok {
+		return 0
+	}
+
+	return buf.VDegree(index)
+}
+
+// SuppressedV reports whether the V value v is related to more distinct Us than the maximum V degree
+func (buf *BipartiteUnionFindWithValues[U, V]) SuppressedV(v V) bool {
+	index, ok := buf.VValues.Lookup(v)
+	return ok && buf.Suppressed(index)
+}
@@ -0,0 +1,158 @@
+package unionfind_test
+
+import (
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxVDegree(t *testing.T) {
+	t.Parallel()
+
+	relations := []unionfind.Relation[string, string]{
+		{U: "alice", V: "gmail.com"},
+		{U: "alice", V: "alice@example.com"},
+		{U: "bob", V: "gmail.com"},
+		{U: "bob", V: "bob@example.com"},
+		{U: "carol", V: "gmail.com"},
+		{U: "carol", V: "alice@example.com"},
+		{U: "dave", V: "gmail.com"},
+	}
+
+	t.Run("UnionAll never bridges suppressed Vs", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.SetMaxVDegree(3)
+		buf.UnionAll(relations, 1)
+
+		assert.Equal(t, []string{"gmail.com"}, buf.SuppressedVs())
+		assert.True(t, buf.SuppressedV("gmail.com"))
+		assert.False(t, buf.SuppressedV("alice@example.com"))
+		assert.Equal(t, 4, buf.VDegreeOf("gmail.com"))
+
+		assert.True(t, buf.UsConnected("alice", "carol"))
+		assert.False(t, buf.UsConnected("alice", "bob"))
+		assert.False(t, buf.VsConnected("gmail.com", "alice@example.com"))
+
+		// Us that only relate to suppressed Vs aren't associated with anything
+		_, ok := buf.FindVRootForU("dave")
+		assert.False(t, ok)
+		assert.False(t, buf.UsConnected("dave", "alice"))
+
+		// Us with other relations keep them
+		root, ok := buf.FindVRootForU("bob")
+		require.True(t, ok)
+		assert.Equal(t, "bob@example.com", root)
+	})
+
+	t.Run("parallel UnionAll", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.SetMaxVDegree(3)
+		buf.UnionAll(relations, 4)
+
+		assert.True(t, buf.UsConnected("alice", "carol"))
+		assert.False(t, buf.UsConnected("alice", "bob"))
+		assert.False(t, buf.UsConnected("dave", "bob"))
+	})
+
+	t.Run("Us only relating to suppressed Vs stay apart", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.SetMaxVDegree(2)
+		buf.UnionAll([]unionfind.Relation[string, string]{
+			{U: "dave", V: "gmail.com"},
+			{U: "eve", V: "gmail.com"},
+			{U: "frank", V: "gmail.com"},
+		}, 1)
+
+		assert.False(t, buf.UsConnected("dave", "eve"))
+		_, ok := buf.FindVRootForU("eve")
+		assert.False(t, ok)
+	})
+
+	t.Run("Union defers relations until Flush", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFind(0)
+		buf.SetMaxVDegree(2)
+		buf.Union(0, 100)
+		buf.Union(1, 100)
+		buf.Union(1, 101)
+		buf.Union(2, 100)
+		buf.Union(2, 102)
+		buf.Union(2, 103)
+
+		assert.Equal(t, 6, buf.Deferred())
+		assert.Equal(t, []int{100}, buf.SuppressedVs())
+		assert.True(t, buf.Suppressed(100))
+		assert.Equal(t, 3, buf.VDegree(100))
+		_, ok := buf.FindAssociatedRoot(2)
+		assert.False(t, ok)
+
+		buf.Flush()
+		assert.Equal(t, 0, buf.Deferred())
+
+		// Relations to the suppressed V never bridged anything, even those made before it passed the limit
+		_, ok = buf.FindAssociatedRoot(0)
+		assert.False(t, ok)
+		root, ok := buf.FindAssociatedRoot(2)
+		require.True(t, ok)
+		assert.Equal(t, buf.Find(102), root)
+		assert.True(t, buf.Connected(102, 103))
+		assert.False(t, buf.Connected(100, 101))
+		assert.False(t, buf.Connected(100, 102))
+		assert.False(t, buf.Connected(101, 102))
+	})
+
+	t.Run("repeated relations count once", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.SetMaxVDegree(2)
+		buf.UnionAll([]unionfind.Relation[string, string]{
+			{U: "alice", V: "gmail.com"},
+			{U: "alice", V: "gmail.com"},
+			{U: "alice", V: "gmail.com"},
+			{U: "bob", V: "gmail.com"},
+		}, 1)
+		buf.Union("bob", "gmail.com")
+		buf.Flush()
+
+		assert.Equal(t, 2, buf.VDegreeOf("gmail.com"))
+		assert.False(t, buf.SuppressedV("gmail.com"))
+		assert.True(t, buf.UsConnected("alice", "bob"))
+	})
+
+	t.Run("removing the limit flushes", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFind(0)
+		buf.SetMaxVDegree(2)
+		buf.Union(0, 100)
+		buf.Union(0, 101)
+
+		buf.SetMaxVDegree(0)
+		assert.Equal(t, 0, buf.Deferred())
+		assert.True(t, buf.Connected(100, 101))
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFindWithValues[string, string](0)
+		buf.UnionAll(relations, 1)
+
+		assert.Empty(t, buf.SuppressedVs())
+		assert.Equal(t, 0, buf.VDegreeOf("gmail.com"))
+		assert.True(t, buf.UsConnected("alice", "bob"))
+	})
+
+	t.Run("encoding keeps degrees and deferred relations", func(t *testing.T) {
+		buf := unionfind.NewBipartiteUnionFind(0)
+		buf.SetMaxVDegree(1)
+		buf.Union(0, 100)
+		buf.Union(1, 100)
+
+		data, err := buf.MarshalBinary()
+		require.NoError(t, err)
+
+		var restored unionfind.BipartiteUnionFind
+		require.NoError(t, restored.UnmarshalBinary(data))
+		assert.Equal(t, buf, &restored)
+		assert.Equal(t, []int{100}, restored.SuppressedVs())
+		assert.Equal(t, 2, restored.Deferred())
+	})
+}
@@ -0,0 +1,264 @@
+package unionfind
+
+import "math"
+
+// TemporalUnionFind is a partially persistent union-find structure
+// that can answer queries as of any earlier time without keeping snapshots.
+// Paths are never compressed, so a link made at time t is never changed again
+// and union by rank keeps trees at logarithmic height. Unions must be made
+// in time order, which means the links on any path to a root were made
+// in increasing time order, and the root as of time t is found
+// by following links until one was made after t.
+type TemporalUnionFind struct {
+	Root        []int
+	Initialized []bool
+	RootCount   int
+	// Rank is the exact size of each set by root index
+	Rank []int
+	// Time each element was linked under its parent, meaningless for roots
+	LinkedAt []int64
+	// Time of the latest union, unions can't be made before it
+	latest int64
+}
+
+// NewTemporalUnionFind creates a new TemporalUnionFind with the specified capacity
+func NewTemporalUnionFind(capacity int) *TemporalUnionFind {
+	return &TemporalUnionFind{
+		Root:        make([]int, capacity),
+		Initialized: make([]bool, capacity),
+		Rank:        make([]int, capacity),
+		LinkedAt:    make([]int64, capacity),
+		latest:      math.MinInt64,
+	}
+}
+
+func (uf *TemporalUnionFind) addElement(n int) {
+	if n >= len(uf.Root) {
+		uf.Root = expandSlice(uf.Root, n)
+		uf.Initialized = expandSlice(uf.Initialized, n)
+		uf.Rank = expandSlice(uf.Rank, n)
+		uf.LinkedAt = expandSlice(uf.LinkedAt, n)
+	}
+
+	if !uf.Initialized[n] {
+		uf.Root[n] = n
+		uf.Initialized[n] = true
+		uf.Rank[n] = 1
+		uf.RootCount++
+	}
+}
+
+// Contains reports whether the element at the given index has been added
+func (uf *TemporalUnionFind) Contains(index int) bool {
+	return index >= 0 && index < len(uf.Initialized) && uf.Initialized[index]
+}
+
+// Find returns the current root of the set containing the given index
+func (uf *TemporalUnionFind) Find(index int) int {
+	uf.addElement(index)
+
+	for uf.Root[index] != index {
+		index = uf.Root[index]
+	}
+
+	return index
+}
+
+// FindAt returns the root as of time t of the set containing the given index,
+// and false if the element has not been added.
+// An element that hadn't been linked to anything by t is its own root.
+func (uf *TemporalUnionFind) FindAt(index int, t int64) (int, bool) {
+	if !uf.Contains(index) {
+		return -1, false
+	}
+
+	for uf.Root[index] != index && uf.LinkedAt[index] <= t {
+		index = uf.Root[index]
+	}
+
+	return index, true
+}
+
+// Union merges the sets containing a and b at time t, returning the root of the merged set.
+// It returns the root of a's set, or -1 if a has not been added, and false
+// without adding or merging anything if t is earlier than the time of a previous union.
+func (uf *TemporalUnionFind) Union(a, b int, t int64) (int, bool) {
+	if t < uf.latest {
+		root, _ := uf.FindAt(a, math.MaxInt64)
+		return root, false
+	}
+	uf.latest = t
+
+	rootA := uf.Find(a)
+	rootB := uf.Find(b)
+
+	if rootA == rootB {
+		return rootA, true
+	}
+
+	if uf.Rank[rootA] < uf.Rank[rootB] {
+		rootA, rootB = rootB, rootA
+	}
+
+	uf.Root[rootB] = rootA
+	uf.LinkedAt[rootB] = t
+	uf.Rank[rootA] += uf.Rank[rootB]
+
+	return rootA, true
+}
+
+// Connected reports whether a and b are currently in the same set.
+// Elements that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *TemporalUnionFind) Connected(a, b int) bool {
+	if !uf.Contains(a) || !uf.Contains(b) {
+		return false
+	}
+
+	return uf.Find(a) == uf.Find(b)
+}
+
+// ConnectedAt reports whether a and b were in the same set as of time t.
+// Elements that have not been added are not connected to anything.
+func (uf *TemporalUnionFind) ConnectedAt(a, b int, t int64) bool {
+	rootA, okA := uf.FindAt(a, t)
+	rootB, okB := uf.FindAt(b, t)
+
+	return okA && okB && rootA == rootB
+}
+
+// ConnectedSince returns the time a and b first became connected,
+// and false if they aren't connected. An element is connected to itself
+// since before any union, which is reported as math.MinInt64.
+func (uf *TemporalUnionFind) ConnectedSince(a, b int) (int64, bool) {
+	if !uf.Contains(a) || !uf.Contains(b) {
+		return 0, false
+	}
+
+	// Follow the earlier of the two links each step,
+	// so the last link followed before the paths meet is the latest
+	since := int64(math.MinInt64)
+	for a != b {
+		rootedA, rootedB := uf.Root[a] == a, uf.Root[b] == b
+		switch {
+		case rootedA && rootedB:
+			return 0, false
+		case rootedB || (!rootedA && uf.LinkedAt[a] <= uf.LinkedAt[b]):
+			since = uf.LinkedAt[a]
+			a = uf.Root[a]
+		default:
+			since = uf.LinkedAt[b]
+			b = uf.Root[b]
+		}
+	}
+
+	return since, true
+}
+
+// TemporalUnionFindWithValues represents a temporal union-find structure with generic values
+type TemporalUnionFindWithValues[T comparable] struct {
+	*TemporalUnionFind
+	values *EnumeratedValues[T]
+}
+
+// NewTemporalUnionFindWithValues creates a new TemporalUnionFindWithValues with the specified capacity
+func NewTemporalUnionFindWithValues[T comparable](capacity int) *TemporalUnionFindWithValues[T] {
+	return &TemporalUnionFindWithValues[T]{
+		TemporalUnionFind: NewTemporalUnionFind(capacity),
+		values:            NewEnumeratedValues[T](capacity),
+	}
+}
+
+// Find returns the current root index of the set containing the given value
+func (uf *TemporalUnionFindWithValues[T]) Find(value T) int {
+	return uf.TemporalUnionFind.Find(uf.values.FetchIndex(value))
+}
+
+// FindReturningValue returns the current root value of the set containing the given value
+func (uf *TemporalUnionFindWithValues[T]) FindReturningValue(value T) T {
+	return uf.values.At(uf.Find(value))
+}
+
+// FindAt returns the root index as of time t of the set containing the given value,
+// and false if the value has not been added. Values are not added by the call.
+func (uf *TemporalUnionFindWithValues[T]) FindAt(value T, t int64) (int, bool) {
+	index, ok := uf.values.Lookup(value)
+	if !ok {
+		return -1, false
+	}
+
+	return uf.TemporalUnionFind.FindAt(index, t)
+}
+
+// FindAtReturningValue is like FindAt but returns the root value
+func (uf *TemporalUnionFindWithValues[T]) FindAtReturningValue(value T, t int64) (T, bool) {
+	root, ok := uf.FindAt(value, t)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	return uf.values.At(root), true
+}
+
+// Contains reports whether the given value has been added
+func (uf *TemporalUnionFindWithValues[T]) Contains(value T) bool {
+	return uf.values.Contains(value)
+}
+
+// Union merges the sets containing values a and b at time t, returning the root index of the merged set.
+// It returns the root index of a's set, or -1 if a has not been added, and false
+// without adding or merging anything if t is earlier than the time of a previous union.
+func (uf *TemporalUnionFindWithValues[T]) Union(a, b T, t int64) (int, bool) {
+	if t < uf.latest {
+		index, ok := uf.values.Lookup(a)
+		if !ok {
+			return -1, false
+		}
+
+		root, _ := uf.TemporalUnionFind.FindAt(index, math.MaxInt64)
+		return root, false
+	}
+
+	return uf.TemporalUnionFind.Union(uf.values.FetchIndex(a), uf.values.FetchIndex(b), t)
+}
+
+// UnionReturningValue is like Union but returns the root value,
+// or the zero value if the union was rejected and a has not been added
+func (uf *TemporalUnionFindWithValues[T]) UnionReturningValue(a, b T, t int64) (T, bool) {
+	root, ok := uf.Union(a, b, t)
+	if root < 0 {
+		var zero T
+		return zero, false
+	}
+
+	return uf.values.At(root), ok
+}
+
+// Connected reports whether values a and b are currently in the same set.
+// Values that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *TemporalUnionFindWithValues[T]) Connected(a, b T) bool {
+	return uf.ConnectedAt(a, b, math.MaxInt64)
+}
+
+// ConnectedAt reports whether values a and b were in the same set as of time t.
+// Values that have not been added are not connected to anything.
+func (uf *TemporalUnionFindWithValues[T]) ConnectedAt(a, b T, t int64) bool {
+	indexA, okA := uf.values.Lookup(a)
+	indexB, okB := uf.values.Lookup(b)
+
+	return okA && okB && uf.TemporalUnionFind.ConnectedAt(indexA, indexB, t)
+}
+
+// ConnectedSince returns the time values a and b first became connected,
+// and false if they aren't connected
+func (uf *TemporalUnionFindWithValues[T]) ConnectedSince(a, b T) (int64, bool) {
+	indexA, okA := uf.values.Lookup(a)
+	indexB, okB := uf.values.Lookup(b)
+	if !okA || !okB {
+		return 0, false
+	}
+
+	return uf.TemporalUnionFind.ConnectedSince(indexA, indexB)
+}
@@ -0,0 +1,158 @@
+package unionfind_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemporalUnionFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("answers queries as of earlier times", func(t *testing.T) {
+		uf := unionfind.NewTemporalUnionFind(0)
+		_, ok := uf.Union(0, 1, 10)
+		require.True(t, ok)
+		_, ok = uf.Union(2, 3, 20)
+		require.True(t, ok)
+		_, ok = uf.Union(1, 3, 30)
+		require.True(t, ok)
+
+		assert.False(t, uf.ConnectedAt(0, 1, 9))
+		assert.True(t, uf.ConnectedAt(0, 1, 10))
+		assert.False(t, uf.ConnectedAt(0, 3, 29))
+		assert.True(t, uf.ConnectedAt(0, 3, 30))
+		assert.True(t, uf.Connected(0, 2))
+		assert.False(t, uf.ConnectedAt(0, 4, 30))
+
+		root, ok := uf.FindAt(2, 25)
+		require.True(t, ok)
+		other, _ := uf.FindAt(3, 25)
+		assert.Equal(t, root, other)
+		zero, _ := uf.FindAt(0, 25)
+		assert.NotEqual(t, root, zero)
+
+		root, ok = uf.FindAt(0, 0)
+		require.True(t, ok)
+		assert.Equal(t, 0, root, "not linked yet")
+		_, ok = uf.FindAt(4, 30)
+		assert.False(t, ok)
+		assert.False(t, uf.Contains(4))
+	})
+
+	t.Run("ConnectedSince", func(t *testing.T) {
+		uf := unionfind.NewTemporalUnionFind(0)
+		uf.Union(0, 1, 10)
+		uf.Union(2, 3, 20)
+		uf.Union(1, 3, 30)
+		uf.Union(4, 0, 40)
+
+		since, ok := uf.ConnectedSince(0, 1)
+		require.True(t, ok)
+		assert.Equal(t, int64(10), since)
+		since, _ = uf.ConnectedSince(3, 2)
+		assert.Equal(t, int64(20), since)
+		since, _ = uf.ConnectedSince(0, 2)
+		assert.Equal(t, int64(30), since)
+		since, _ = uf.ConnectedSince(4, 1)
+		assert.Equal(t, int64(40), since)
+		since, _ = uf.ConnectedSince(4, 4)
+		assert.Equal(t, int64(math.MinInt64), since)
+
+		uf.Find(5)
+		_, ok = uf.ConnectedSince(0, 5)
+		assert.False(t, ok)
+	})
+
+	t.Run("refuses unions out of time order", func(t *testing.T) {
+		uf := unionfind.NewTemporalUnionFind(0)
+		_, ok := uf.Union(0, 1, 10)
+		require.True(t, ok)
+		root, ok := uf.Union(2, 3, 5)
+		assert.False(t, ok)
+		assert.Equal(t, -1, root)
+		assert.False(t, uf.Contains(2), "a rejected union adds nothing")
+		assert.False(t, uf.Contains(3))
+		assert.Equal(t, 2, uf.RootCount)
+
+		root, ok = uf.Union(1, 3, 5)
+		assert.False(t, ok)
+		assert.Equal(t, uf.Find(0), root)
+
+		_, ok = uf.Union(2, 3, 10)
+		assert.True(t, ok, "unions at the same time are fine")
+
+		values := unionfind.NewTemporalUnionFindWithValues[string](0)
+		values.Union("alice", "bob", 10)
+		value, ok := values.UnionReturningValue("carol", "dave", 5)
+		assert.False(t, ok)
+		assert.Empty(t, value)
+		assert.False(t, values.Contains("carol"))
+		assert.False(t, values.Contains("dave"))
+	})
+
+	t.Run("matches replaying unions up to each time", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 200
+
+		uf := unionfind.NewTemporalUnionFind(0)
+		edges := make([][2]int, n)
+		for i := range edges {
+			edges[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+			_, ok := uf.Union(edges[i][0], edges[i][1], int64(i/2))
+			require.True(t, ok)
+		}
+
+		for at := int64(-1); at < n/2; at += 7 {
+			replay := unionfind.NewUnionFind(n)
+			for i, edge := range edges {
+				if int64(i/2) <= at {
+					replay.Union(edge[0], edge[1])
+				}
+			}
+
+			for range n {
+				a, b := rng.Intn(n), rng.Intn(n)
+				if !uf.Contains(a) || !uf.Contains(b) {
+					continue
+				}
+				connected := a == b || replay.Connected(a, b)
+				require.Equal(t, connected, uf.ConnectedAt(a, b, at), "%d and %d at %d", a, b, at)
+
+				if since, ok := uf.ConnectedSince(a, b); ok && a != b {
+					assert.Equal(t, since <= at, connected)
+				}
+			}
+		}
+	})
+
+	t.Run("values", func(t *testing.T) {
+		uf := unionfind.NewTemporalUnionFindWithValues[string](0)
+		uf.Union("alice", "10.0.0.1", 100)
+		root, ok := uf.UnionReturningValue("mallory", "10.0.0.1", 200)
+		require.True(t, ok)
+		assert.Equal(t, "alice", root)
+
+		assert.True(t, uf.Connected("alice", "mallory"))
+		assert.False(t, uf.ConnectedAt("alice", "mallory", 150))
+		assert.True(t, uf.ConnectedAt("alice", "10.0.0.1", 150))
+		assert.False(t, uf.ConnectedAt("alice", "bob", 200))
+		assert.False(t, uf.Contains("bob"))
+
+		value, ok := uf.FindAtReturningValue("mallory", 150)
+		require.True(t, ok)
+		assert.Equal(t, "mallory", value)
+		value, _ = uf.FindAtReturningValue("mallory", 200)
+		assert.Equal(t, "alice", value)
+		assert.Equal(t, "alice", uf.FindReturningValue("10.0.0.1"))
+
+		since, ok := uf.ConnectedSince("mallory", "alice")
+		require.True(t, ok)
+		assert.Equal(t, int64(200), since)
+	})
+}
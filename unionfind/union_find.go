@@ -1,5 +1,13 @@
 package unionfind
 
+import (
+	"cmp"
+	"iter"
+	"runtime"
+	"slices"
+	"sync"
+)
+
 // https://en.wikipedia.org/wiki/Disjoint-set_data_structure
 
 // UnionFind represents a union-find (disjoint set) data structure
@@ -7,15 +15,39 @@ type UnionFind struct {
 	Root        []int  // Parent of each element by index
 	Initialized []bool // Whether the element has been initialized
 	RootCount   int    // Number of roots
-	// Upper bound cardinality of each set by root index.
+	// Number of sets with more than one member
+	NonSingletonCount int
+	// Cardinality of each set by root index.
 	// this is used to weight the union operation
 	// to keep the tree as flat as possible by
 	// preferring to make the smaller tree a child
 	// of the larger tree in union operations.
-	// Not expected to be an exact representation of
-	// cardinality as union operations are performed.
+	// Only exact for root indices, the values at
+	// non-root indices are left stale once they are merged.
 	// see https://stackoverflow.com/a/69063833
 	Rank []int
+
+	// Changes recorded in rollback mode
+	changeLog
+
+	// Which member of each set is reported as its root, see SetRootPolicy
+	rootPolicy RootPolicy
+	// Whether member a should be reported as the root over member b, nil for RankRoot
+	prefer func(a, b int) bool
+	// Member reported as the root by root index, nil for RankRoot
+	representative []int
+	// Order elements were added in by index, only kept for FirstSeenRoot
+	addedAt []int
+
+	// Largest set a union may create, 0 for no limit, see SetMaxSetSize
+	maxSetSize int
+	// Edges whose union was rejected for exceeding maxSetSize
+	rejected [][2]int
+
+	// Unions that merged sets, nil unless provenance is enabled
+	provenance *provenance
+	// Every edge passed to Union, nil unless edge retention is enabled
+	retained *retainedEdges
 }
 
 // NewUnionFind creates a new UnionFind with the specified capacity
@@ -27,25 +59,60 @@ func NewUnionFind(capacity int) *UnionFind {
 	}
 }
 
-func (uf *UnionFind) addElement(n int) {
-	if n >= len(uf.Root) {
-		uf.Root = expandSlice(uf.Root, n)
-		uf.Initialized = expandSlice(uf.Initialized, n)
-		uf.Rank = expandSlice(uf.Rank, n)
+// grow makes room for elements up to index n
+func (uf *UnionFind) grow(n int) {
+	if n < len(uf.Root) {
+		return
+	}
+
+	uf.Root = expandSlice(uf.Root, n)
+	uf.Initialized = expandSlice(uf.Initialized, n)
+	uf.Rank = expandSlice(uf.Rank, n)
+	if uf.representative != nil {
+		uf.representative = expandSlice(uf.representative, n)
+	}
+	if uf.addedAt != nil {
+		uf.addedAt = expandSlice(uf.addedAt, n)
 	}
+}
+
+func (uf *UnionFind) addElement(n int) {
+	uf.grow(n)
 
 	if !uf.Initialized[n] {
 		uf.Root[n] = n
 		uf.Initialized[n] = true
 		uf.Rank[n] = 1
+		if uf.representative != nil {
+			uf.representative[n] = n
+		}
+		if uf.addedAt != nil {
+			uf.addedAt[n] = uf.RootCount
+		}
 		uf.RootCount++
+		uf.record(change{index: n, parent: -1})
 	}
 }
 
 // Find returns the root of the set containing the given index
 func (uf *UnionFind) Find(index int) int {
+	return uf.representativeOf(uf.findRoot(index))
+}
+
+// findRoot returns the root of the tree containing the given index,
+// which is only reported as the root of the set under RankRoot
+func (uf *UnionFind) findRoot(index int) int {
 	uf.addElement(index)
 
+	// Compressed paths can't be rolled back
+	if uf.rollback {
+		for uf.Root[index] != index {
+			index = uf.Root[index]
+		}
+
+		return index
+	}
+
 	for uf.Root[index] != index {
 		uf.Root[index] = uf.Root[uf.Root[index]] // Path compression
 		index = uf.Root[index]
@@ -54,24 +121,290 @@ func (uf *UnionFind) Find(index int) int {
 	return index
 }
 
-// Union merges the sets containing a and b, returning the root of the merged set
+// Contains reports whether the element at the given index has been added
+func (uf *UnionFind) Contains(index int) bool {
+	return index >= 0 && index < len(uf.Initialized) && uf.Initialized[index]
+}
+
+// TryFind returns the root of the set containing the given index
+// and false if the element has not been added.
+// Unlike Find it never adds elements or compresses paths,
+// so it leaves the structure unchanged.
+func (uf *UnionFind) TryFind(index int) (int, bool) {
+	root, ok := uf.tryFindRoot(index)
+	if !ok {
+		return -1, false
+	}
+
+	return uf.representativeOf(root), true
+}
+
+// tryFindRoot returns the root of the tree containing the given index
+// and false if the element has not been added, leaving the structure unchanged
+func (uf *UnionFind) tryFindRoot(index int) (int, bool) {
+	if !uf.Contains(index) {
+		return -1, false
+	}
+
+	for uf.Root[index] != index {
+		index = uf.Root[index]
+	}
+
+	return index, true
+}
+
+// Connected reports whether a and b are in the same set.
+// Elements that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *UnionFind) Connected(a, b int) bool {
+	if !uf.Contains(a) || !uf.Contains(b) {
+		return false
+	}
+
+	return uf.findRoot(a) == uf.findRoot(b)
+}
+
+// Union merges the sets containing a and b, returning the root of the merged set.
+// If the merged set would be larger than the maximum set size the edge is
+// recorded in RejectedEdges instead, and the root of a's set is returned.
 func (uf *UnionFind) Union(a, b int) int {
-	rootA := uf.Find(a)
-	rootB := uf.Find(b)
+	separate := uf.provenance != nil && uf.findRoot(a) != uf.findRoot(b)
+
+	root, ok := uf.union(a, b)
+	if !ok {
+		uf.reject(a, b)
+		return root
+	}
+
+	if separate {
+		uf.provenance.record([2]int{a, b}, a, b)
+	}
+	uf.retain(a, b)
+
+	return root
+}
+
+// union merges the sets containing a and b, returning the root of the merged set
+// and false without merging if it would be larger than the maximum set size
+func (uf *UnionFind) union(a, b int) (int, bool) {
+	rootA := uf.findRoot(a)
+	rootB := uf.findRoot(b)
 
 	if rootA != rootB {
+		if uf.maxSetSize > 0 && uf.Rank[rootA]+uf.Rank[rootB] > uf.maxSetSize {
+			return uf.representativeOf(rootA), false
+		}
+
+		uf.countMerge(rootA, rootB)
+
 		if uf.Rank[rootA] < uf.Rank[rootB] {
-			uf.Root[rootA] = rootB
-			uf.Rank[rootB] += uf.Rank[rootA]
+			uf.link(rootA, rootB)
+
+			return uf.representativeOf(rootB), true
+		}
+
+		uf.link(rootB, rootA)
+
+		return uf.representativeOf(rootA), true
+	}
+
+	return uf.representativeOf(rootA), true
+}
+
+// SetMaxSetSize limits the size of the sets unions may create,
+// protecting against a single noisy element merging everything into one giant set.
+// Unions that would exceed it are rejected and recorded in RejectedEdges.
+// Sets that are already larger are left as they are.
+// A size of 0 or less removes the limit.
+func (uf *UnionFind) SetMaxSetSize(size int) {
+	uf.maxSetSize = max(size, 0)
+}
+
+// MaxSetSize returns the largest set a union may create, 0 if there's no limit
+func (uf *UnionFind) MaxSetSize() int {
+	return uf.maxSetSize
+}
+
+// RejectedEdges returns the edges whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (uf *UnionFind) RejectedEdges() [][2]int {
+	return slices.Clone(uf.rejected)
+}
+
+func (uf *UnionFind) reject(a, b int) {
+	uf.rejected = append(uf.rejected, [2]int{a, b})
+}
+
+// link makes root a child of parent
+func (uf *UnionFind) link(root, parent int) {
+	c := change{index: root, parent: parent}
+
+	uf.Root[root] = parent
+	uf.Rank[parent] += uf.Rank[root]
+	if uf.representative != nil {
+		c.representative = uf.representative[parent]
+		uf.representative[parent] = uf.preferredOf(uf.representative[parent], uf.representative[root])
+	}
+
+	uf.record(c)
+}
+
+// UnionAll merges the sets containing both ends of every edge,
+// splitting the edges between workers goroutines.
+// The workers union their share of the edges into a ConcurrentUnionFind
+// seeded with the sets already in uf, which is then copied back into uf.
+// The resulting sets are the same as calling Union for each edge
+// but the roots chosen for them may differ.
+// When workers is less than 1, runtime.GOMAXPROCS workers are used.
+// Copying costs time proportional to the capacity of uf, so batches with fewer edges
+// than that are unioned one at a time instead, as they are in rollback mode so
+// they can be rolled back, when there's a maximum set size so the same edges are rejected,
+// and in provenance mode so the unions that merge sets are recorded.
+func (uf *UnionFind) UnionAll(edges [][2]int, workers int) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(edges))
+
+	if workers <= 1 || len(edges) < len(uf.Root) ||
+		uf.rollback || uf.maxSetSize > 0 || uf.provenance != nil {
+		for _, edge := range edges {
+			uf.Union(edge[0], edge[1])
+		}
+		return
+	}
+
+	capacity := len(uf.Root)
+	for _, edge := range edges {
+		capacity = max(capacity, edge[0]+1, edge[1]+1)
+	}
+
+	for _, edge := range edges {
+		uf.retain(edge[0], edge[1])
+	}
+
+	forest := newConcurrentUnionFindFrom(uf, capacity)
+	chunkSize := (len(edges) + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for start := 0; start < len(edges); start += chunkSize {
+		chunk := edges[start:min(start+chunkSize, len(edges))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, edge := range chunk {
+				forest.union(edge[0], edge[1])
+			}
+		}()
+	}
+	wg.Wait()
+
+	forest.copyTo(uf)
+}
+
+// countMerge keeps NonSingletonCount up to date for a merge of the sets rooted at a and b
+func (uf *UnionFind) countMerge(rootA, rootB int) {
+	uf.NonSingletonCount++
+	if uf.Rank[rootA] > 1 {
+		uf.NonSingletonCount--
+	}
+	if uf.Rank[rootB] > 1 {
+		uf.NonSingletonCount--
+	}
+}
+
+// Size returns the exact number of elements in the set containing the given index,
+// 0 if the element has not been added. Elements are not added by the call.
+func (uf *UnionFind) Size(index int) int {
+	root, ok := uf.tryFindRoot(index)
+	if !ok {
+		return 0
+	}
+
+	return uf.Rank[root]
+}
+
+// LargestSets returns the root indices of the k largest sets, largest first.
+// Sets of equal size are ordered by root index.
+func (uf *UnionFind) LargestSets(k int) []int {
+	if k <= 0 {
+		return nil
+	}
+
+	roots := slices.Collect(uf.treeRoots())
+	slices.SortStableFunc(roots, func(a, b int) int {
+		return cmp.Compare(uf.Rank[b], uf.Rank[a])
+	})
+
+	if k < len(roots) {
+		roots = roots[:k]
+	}
+	for i, root := range roots {
+		roots[i] = uf.representativeOf(root)
+	}
+
+	return roots
+}
 
-			return rootB
+// Members returns the indices of all elements in the set containing index,
+// nil if the element has not been added. Elements are not added by the call.
+func (uf *UnionFind) Members(index int) []int {
+	root, ok := uf.tryFindRoot(index)
+	if !ok {
+		return nil
+	}
+
+	var members []int
+	for i, initialized := range uf.Initialized {
+		if initialized {
+			if memberRoot, _ := uf.tryFindRoot(i); memberRoot == root {
+				members = append(members, i)
+			}
 		}
+	}
 
-		uf.Root[rootB] = rootA
-		uf.Rank[rootA] += uf.Rank[rootB]
+	return members
+}
+
+// Roots returns an iterator over the root index of every set
+func (uf *UnionFind) Roots() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for root := range uf.treeRoots() {
+			if !yield(uf.representativeOf(root)) {
+				return
+			}
+		}
+	}
+}
 
-		return rootA
+func (uf *UnionFind) treeRoots() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i, initialized := range uf.Initialized {
+			if initialized && uf.Root[i] == i && !yield(i) {
+				return
+			}
+		}
 	}
+}
 
-	return rootA
+// Sets returns an iterator over every set, yielding its root index
+// along with the indices of all of its members.
+// Sets are yielded in ascending order of the index of their tree root,
+// which is their root index under RankRoot.
+func (uf *UnionFind) Sets() iter.Seq2[int, []int] {
+	return func(yield func(int, []int) bool) {
+		members := make(map[int][]int)
+		for i, initialized := range uf.Initialized {
+			if initialized {
+				root := uf.Find(i)
+				members[root] = append(members[root], i)
+			}
+		}
+
+		for root := range uf.Roots() {
+			if !yield(root, members[root]) {
+				return
+			}
+		}
+	}
 }
@@ -1,11 +1,13 @@
 package unionfind_test
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/maxjustus/bpuf/unionfind"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUnionFind(t *testing.T) {
@@ -32,4 +34,208 @@ func TestUnionFind(t *testing.T) {
 		assert.Equal(t, 700, uf.Find(1000),
 			"1000 should be in the same set as 700 after union of 801 and 1000")
 	})
+
+	t.Run("Members/Sets/Roots", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		uf.Union(3, 4)
+		uf.Union(2, 4)
+		uf.Union(6, 7)
+		uf.Find(9)
+
+		assert.Equal(t, []int{1, 2, 3, 4}, uf.Members(3))
+		assert.Equal(t, []int{9}, uf.Members(9))
+		assert.Nil(t, uf.Members(20))
+		assert.False(t, uf.Contains(20), "Members should not add elements")
+
+		var roots []int
+		for root := range uf.Roots() {
+			roots = append(roots, root)
+		}
+		assert.Equal(t, []int{1, 6, 9}, roots)
+
+		sets := make(map[int][]int)
+		for root, members := range uf.Sets() {
+			sets[root] = members
+		}
+		assert.Equal(t, map[int][]int{
+			1: {1, 2, 3, 4},
+			6: {6, 7},
+			9: {9},
+		}, sets)
+	})
+
+	t.Run("Size/LargestSets", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		uf.Union(3, 4)
+		assert.Equal(t, 2, uf.NonSingletonCount)
+		uf.Union(2, 4)
+		assert.Equal(t, 1, uf.NonSingletonCount)
+		uf.Union(6, 7)
+		uf.Union(1, 3)
+		uf.Find(9)
+
+		assert.Equal(t, 4, uf.Size(3))
+		assert.Equal(t, 2, uf.Size(7))
+		assert.Equal(t, 1, uf.Size(9))
+		assert.Equal(t, 0, uf.Size(20))
+		assert.Equal(t, 0, uf.Size(-1))
+		assert.False(t, uf.Contains(20), "Size should not add elements")
+		assert.Equal(t, 2, uf.NonSingletonCount)
+
+		assert.Equal(t, []int{1, 6}, uf.LargestSets(2))
+		assert.Equal(t, []int{1, 6, 9}, uf.LargestSets(10))
+		assert.Empty(t, uf.LargestSets(0))
+	})
+
+	t.Run("Contains/TryFind", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(10)
+		uf.Union(1, 2)
+		uf.Union(2, 3)
+
+		root, ok := uf.TryFind(3)
+		assert.True(t, ok)
+		assert.Equal(t, 1, root)
+		assert.True(t, uf.Contains(3))
+
+		rootCount := uf.RootCount
+		_, ok = uf.TryFind(5)
+		assert.False(t, ok)
+		_, ok = uf.TryFind(500)
+		assert.False(t, ok)
+		assert.False(t, uf.Contains(5))
+		assert.False(t, uf.Contains(-1))
+		assert.Equal(t, rootCount, uf.RootCount, "lookups should not add elements")
+		assert.Len(t, uf.Root, 10)
+	})
+
+	t.Run("Connected", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(1, 2)
+		uf.Union(3, 4)
+		uf.Union(2, 4)
+		uf.Union(5, 6)
+
+		assert.True(t, uf.Connected(1, 3))
+		assert.True(t, uf.Connected(4, 4))
+		assert.False(t, uf.Connected(1, 5))
+		assert.False(t, uf.Connected(1, 100))
+		assert.False(t, uf.Contains(100), "Connected should not add elements")
+	})
+
+	t.Run("UnionAll", func(t *testing.T) {
+		const n = 5000
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		edges := make([][2]int, n)
+		for i := range edges {
+			edges[i] = [2]int{rng.Intn(2 * n), rng.Intn(2 * n)}
+		}
+
+		expected := unionfind.NewUnionFind(0)
+		for _, edge := range edges {
+			expected.Union(edge[0], edge[1])
+		}
+
+		contained := make([]bool, 2*n)
+		for i := range contained {
+			contained[i] = expected.Contains(i)
+		}
+
+		for _, workers := range []int{0, 1, 3, 8} {
+			uf := unionfind.NewUnionFind(0)
+			uf.UnionAll(edges, workers)
+
+			assert.Equal(t, expected.NonSingletonCount, uf.NonSingletonCount)
+			for i := 0; i < 2*n; i++ {
+				require.Equal(t, contained[i], uf.Contains(i))
+				if contained[i] {
+					require.Equal(t, expected.Size(i), uf.Size(i))
+				}
+			}
+			assertSamePartition(t, 2*n, expected.Find, uf.Find)
+		}
+
+		// Sets that are already in the structure are kept
+		uf := unionfind.NewUnionFind(0)
+		uf.Union(0, 1)
+		uf.UnionAll(append(edges, [2]int{1, 2 * n}), 4)
+		assert.True(t, uf.Connected(0, 2*n))
+		assert.True(t, uf.Connected(edges[0][0], edges[0][1]))
+
+		// Batches smaller than the structure are unioned one at a time
+		uf = unionfind.NewUnionFind(4 * n)
+		sequential := unionfind.NewUnionFind(4 * n)
+		uf.UnionAll(edges[:10], 4)
+		for _, edge := range edges[:10] {
+			sequential.Union(edge[0], edge[1])
+			assert.True(t, uf.Connected(edge[0], edge[1]))
+		}
+		assert.Equal(t, sequential, uf)
+	})
+
+	t.Run("maximum set size", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.SetMaxSetSize(3)
+		uf.Union(0, 1)
+		uf.Union(1, 2)
+		uf.Union(3, 4)
+
+		root := uf.Union(4, 0)
+		assert.Equal(t, uf.Find(4), root)
+		assert.False(t, uf.Connected(0, 4))
+		assert.Equal(t, 3, uf.Size(0))
+
+		// Unions within a set never grow it
+		uf.Union(2, 0)
+		uf.UnionAll([][2]int{{5, 3}, {6, 2}, {6, 7}}, 4)
+		assert.Equal(t, [][2]int{{4, 0}, {6, 2}}, uf.RejectedEdges())
+		assert.True(t, uf.Connected(3, 5))
+		assert.True(t, uf.Connected(6, 7))
+
+		uf.SetMaxSetSize(0)
+		uf.Union(4, 0)
+		assert.Equal(t, 6, uf.Size(0))
+		assert.Len(t, uf.RejectedEdges(), 2)
+	})
+
+	t.Run("rejected edges are rolled back", func(t *testing.T) {
+		uf := unionfind.NewUnionFind(0)
+		uf.SetMaxSetSize(2)
+		uf.Union(0, 1)
+		uf.Union(1, 2)
+
+		checkpoint := uf.Checkpoint()
+		uf.Union(2, 0)
+		uf.Union(0, 3)
+		assert.Len(t, uf.RejectedEdges(), 3)
+
+		require.NoError(t, uf.Rollback(checkpoint))
+		assert.Equal(t, [][2]int{{1, 2}}, uf.RejectedEdges())
+	})
+}
+
+func BenchmarkUnionFind(b *testing.B) {
+	const n = 1000000
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+	edges := make([][2]int, n)
+	for i := range edges {
+		edges[i] = [2]int{rng.Intn(n), rng.Intn(n)}
+	}
+
+	b.Run("Union() 1M edges", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			uf := unionfind.NewUnionFind(n)
+			for _, edge := range edges {
+				uf.Union(edge[0], edge[1])
+			}
+		}
+	})
+
+	b.Run("UnionAll() 1M edges", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			uf := unionfind.NewUnionFind(n)
+			uf.UnionAll(edges, 0)
+		}
+	})
 }
@@ -1,5 +1,7 @@
 package unionfind
 
+import "iter"
+
 // AlgoUnionFindWithValues represents a union-find structure with generic values
 type AlgoUnionFindWithValues[T comparable] struct {
 	*UnionFind
@@ -24,6 +26,58 @@ func (uf *AlgoUnionFindWithValues[T]) FindReturningValue(value T) T {
 	return uf.values.At(uf.Find(value))
 }
 
+// Contains reports whether the given value has been added
+func (uf *AlgoUnionFindWithValues[T]) Contains(value T) bool {
+	return uf.values.Contains(value)
+}
+
+// TryFind returns the root index of the set containing the given value
+// and false if the value has not been added. The structure is left unchanged.
+func (uf *AlgoUnionFindWithValues[T]) TryFind(value T) (int, bool) {
+	index, ok := uf.values.Lookup(value)
+	if !ok {
+		return -1, false
+	}
+
+	return uf.UnionFind.TryFind(index)
+}
+
+// TryFindReturningValue returns the root value of the set containing the given value
+// and false if the value has not been added. The structure is left unchanged.
+func (uf *AlgoUnionFindWithValues[T]) TryFindReturningValue(value T) (T, bool) {
+	root, ok := uf.TryFind(value)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	return uf.values.At(root), true
+}
+
+// Connected reports whether values a and b are in the same set.
+// Values that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *AlgoUnionFindWithValues[T]) Connected(a, b T) bool {
+	indexA, okA := uf.values.Lookup(a)
+	indexB, okB := uf.values.Lookup(b)
+	if !okA || !okB {
+		return false
+	}
+
+	return uf.UnionFind.Connected(indexA, indexB)
+}
+
+// SetRootCmp reports the smallest member of each set by cmp as its root,
+// falling back to the value that was seen first for members that compare equal.
+// Functions can't be encoded, so MarshalBinary returns ErrRootCmpNotEncodable
+// until another policy is set with SetRootPolicy.
+func (uf *AlgoUnionFindWithValues[T]) SetRootCmp(cmp func(a, b T) int) {
+	uf.setPreference(cmpRoot, func(a, b int) bool {
+		c := cmp(uf.values.At(a), uf.values.At(b))
+		return c < 0 || (c == 0 && a < b)
+	})
+}
+
 // Union merges the sets containing values a and b, returning the root index
 func (uf *AlgoUnionFindWithValues[T]) Union(a, b T) int {
 	indexA := uf.values.FetchIndex(a)
@@ -32,6 +86,18 @@ func (uf *AlgoUnionFindWithValues[T]) Union(a, b T) int {
 	return uf.UnionFind.Union(indexA, indexB)
 }
 
+// UnionAll merges the sets containing both values of every pair.
+// Values are enumerated up front, then the unions are split between workers
+// goroutines as described in UnionFind.UnionAll.
+func (uf *AlgoUnionFindWithValues[T]) UnionAll(pairs [][2]T, workers int) {
+	edges := make([][2]int, len(pairs))
+	for i, pair := range pairs {
+		edges[i] = [2]int{uf.values.FetchIndex(pair[0]), uf.values.FetchIndex(pair[1])}
+	}
+
+	uf.UnionFind.UnionAll(edges, workers)
+}
+
 // UnionReturningValue merges the sets containing values a and b, returning the root value.
 // This is handy for directly getting new root value, but is slower
 // because it looks up value by index.
@@ -39,3 +105,99 @@ func (uf *AlgoUnionFindWithValues[T]) UnionReturningValue(a, b T) T {
 	idx := uf.Union(a, b)
 	return uf.values.At(idx)
 }
+
+// RejectedEdges returns the pairs of values whose union was rejected
+// for exceeding the maximum set size, in the order they were rejected
+func (uf *AlgoUnionFindWithValues[T]) RejectedEdges() [][2]T {
+	pairs := make([][2]T, len(uf.rejected))
+	for i, edge := range uf.rejected {
+		pairs[i] = [2]T{uf.values.At(edge[0]), uf.values.At(edge[1])}
+	}
+
+	return pairs
+}
+
+// Members returns all values in the set containing the given value.
+// Values that have not been added have no members and are not added by the call.
+func (uf *AlgoUnionFindWithValues[T]) Members(value T) []T {
+	index, ok := uf.values.Lookup(value)
+	if !ok {
+		return nil
+	}
+
+	return uf.values.AtEach(uf.UnionFind.Members(index))
+}
+
+// Roots returns an iterator over the root value of every set
+func (uf *AlgoUnionFindWithValues[T]) Roots() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for root := range uf.UnionFind.Roots() {
+			if !yield(uf.values.At(root)) {
+				return
+			}
+		}
+	}
+}
+
+// Sets returns an iterator over every set, yielding its root value
+// along with the values of all of its members
+func (uf *AlgoUnionFindWithValues[T]) Sets() iter.Seq2[T, []T] {
+	return func(yield func(T, []T) bool) {
+		for root, members := range uf.UnionFind.Sets() {
+			if !yield(uf.values.At(root), uf.values.AtEach(members)) {
+				return
+			}
+		}
+	}
+}
+
+// SizeOf returns the exact number of values in the set containing the given value,
+// 0 if the value has not been added. Values are not added by the call.
+func (uf *AlgoUnionFindWithValues[T]) SizeOf(value T) int {
+	index, ok := uf.values.Lookup(value)
+	if !ok {
+		return 0
+	}
+
+	return uf.Size(index)
+}
+
+// LargestSets returns the root values of the k largest sets, largest first
+func (uf *AlgoUnionFindWithValues[T]) LargestSets(k int) []T {
+	return uf.values.AtEach(uf.UnionFind.LargestSets(k))
+}
+
+// Checkpoint returns a checkpoint of the current state for Rollback,
+// switching to rollback mode if it isn't enabled yet
+func (uf *AlgoUnionFindWithValues[T]) Checkpoint() Checkpoint {
+	checkpoint := uf.UnionFind.Checkpoint()
+	checkpoint.values = len(uf.values.IndexedElements)
+	return checkpoint
+}
+
+// Rollback restores the exact state at the given checkpoint,
+// undoing every union and removing every value added since,
+// including values that reused the index of a removed value.
+// Checkpoints taken after the given checkpoint become stale.
+// It returns ErrStaleCheckpoint without changing anything if the checkpoint is stale.
+func (uf *AlgoUnionFindWithValues[T]) Rollback(checkpoint Checkpoint) error {
+	if err := uf.checkCheckpoint(checkpoint); err != nil {
+		return err
+	}
+
+	// Values that took a freed index are removed in reverse so the freed indices
+	// end up in the order they were before
+	var reused []int
+	for _, c := range uf.changes[checkpoint.changes:] {
+		if !c.retained && c.parent < 0 && c.index < checkpoint.values {
+			reused = append(reused, c.index)
+		}
+	}
+
+	uf.rollbackTo(checkpoint)
+	for i := len(reused) - 1; i >= 0; i-- {
+		uf.values.Remove(uf.values.At(reused[i]))
+	}
+	uf.values.truncate(checkpoint.values)
+	return nil
+}
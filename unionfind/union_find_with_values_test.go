@@ -18,4 +18,100 @@ func TestUnionFindWithValues(t *testing.T) {
 		root = uf.UnionReturningValue("B", "C")
 		assert.Equal(t, "A", root)
 	})
+
+	t.Run("Members/Sets/Roots", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("A", "B")
+		uf.Union("C", "D")
+		uf.Union("B", "C")
+		uf.Union("E", "F")
+
+		assert.Equal(t, []string{"A", "B", "C", "D"}, uf.Members("D"))
+
+		var roots []string
+		for root := range uf.Roots() {
+			roots = append(roots, root)
+		}
+		assert.Equal(t, []string{"A", "E"}, roots)
+
+		sets := make(map[string][]string)
+		for root, members := range uf.Sets() {
+			sets[root] = members
+		}
+		assert.Equal(t, map[string][]string{
+			"A": {"A", "B", "C", "D"},
+			"E": {"E", "F"},
+		}, sets)
+	})
+
+	t.Run("SizeOf/LargestSets", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("A", "B")
+		uf.Union("B", "C")
+		uf.Union("D", "E")
+
+		assert.Equal(t, 3, uf.SizeOf("C"))
+		assert.Equal(t, 2, uf.SizeOf("D"))
+		assert.Equal(t, []string{"A"}, uf.LargestSets(1))
+	})
+
+	t.Run("Contains/TryFind", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("A", "B")
+
+		root, ok := uf.TryFindReturningValue("B")
+		assert.True(t, ok)
+		assert.Equal(t, "A", root)
+		assert.True(t, uf.Contains("A"))
+
+		_, ok = uf.TryFind("typo")
+		assert.False(t, ok)
+		_, ok = uf.TryFindReturningValue("typo")
+		assert.False(t, ok)
+		assert.False(t, uf.Contains("typo"))
+		assert.Equal(t, 2, uf.RootCount, "lookups should not add values")
+	})
+
+	t.Run("Connected", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.Union("alice", "bob")
+		uf.Union("charlie", "dave")
+		uf.Union("bob", "charlie")
+		uf.Union("erin", "frank")
+
+		assert.True(t, uf.Connected("alice", "dave"))
+		assert.False(t, uf.Connected("alice", "erin"))
+		assert.False(t, uf.Connected("alice", "mallory"))
+		assert.False(t, uf.Contains("mallory"), "Connected should not add values")
+	})
+
+	t.Run("UnionAll", func(t *testing.T) {
+		pairs := [][2]string{{"A", "B"}, {"C", "D"}, {"B", "C"}, {"E", "F"}, {"G", "G"}}
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.UnionAll(pairs, 2)
+
+		assert.True(t, uf.Connected("A", "D"))
+		assert.True(t, uf.Connected("E", "F"))
+		assert.False(t, uf.Connected("A", "E"))
+		assert.Equal(t, 1, uf.SizeOf("G"))
+		assert.Equal(t, 0, uf.SizeOf("H"))
+		assert.Nil(t, uf.Members("H"))
+		assert.False(t, uf.Contains("H"), "SizeOf and Members should not add values")
+		assert.Equal(t, 7, uf.RootCount)
+	})
+
+	t.Run("maximum set size", func(t *testing.T) {
+		uf := unionfind.NewUnionFindWithValues[string](0)
+		uf.SetMaxSetSize(3)
+		uf.UnionAll([][2]string{
+			{"alice", "10.0.0.1"},
+			{"bob", "10.0.0.1"},
+			{"carol", "10.0.0.1"},
+			{"carol", "carol@example.com"},
+		}, 1)
+
+		assert.Equal(t, [][2]string{{"carol", "10.0.0.1"}}, uf.RejectedEdges())
+		assert.True(t, uf.Connected("carol", "carol@example.com"))
+		assert.False(t, uf.Connected("alice", "carol"))
+	})
 }
@@ -0,0 +1,266 @@
+package unionfind
+
+import "math"
+
+// WeightedUnionFind is a union-find structure that also keeps the relative potential
+// between the elements of each set, such as "a's timestamp is b's plus 5 seconds".
+// Potentials are combined by a Group, which must be abelian.
+// see https://en.wikipedia.org/wiki/Disjoint-set_data_structure
+type WeightedUnionFind[W any] struct {
+	Root        []int  // Parent of each element by index
+	Initialized []bool // Whether the element has been initialized
+	RootCount   int    // Number of roots
+	// Cardinality of each set by root index, see UnionFind.Rank
+	Rank []int
+	// Potential of each element relative to its parent,
+	// the potential of an element is its parent's combined with it
+	Potential []W
+	group     Group[W]
+}
+
+// Group combines the potentials of a WeightedUnionFind
+type Group[W any] interface {
+	// Identity returns the potential of an element relative to itself
+	Identity() W
+	// Combine returns the potential b relative to a, given a is relative to some element
+	Combine(a, b W) W
+	// Inverse returns the potential that combines with a to give the identity
+	Inverse(a W) W
+	// Equal reports whether two potentials are the same
+	Equal(a, b W) bool
+}
+
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+type number interface {
+	integer | ~float32 | ~float64
+}
+
+// AdditiveGroup combines potentials by adding them, such as offsets between timestamps.
+// Potentials are compared exactly.
+type AdditiveGroup[N number] struct{}
+
+// Identity implements Group
+func (AdditiveGroup[N]) Identity() N { return 0 }
+
+// Combine implements Group
+func (AdditiveGroup[N]) Combine(a, b N) N { return a + b }
+
+// Inverse implements Group
+func (AdditiveGroup[N]) Inverse(a N) N { return -a }
+
+// Equal implements Group
+func (AdditiveGroup[N]) Equal(a, b N) bool { return a == b }
+
+// MultiplicativeGroup combines potentials by multiplying them, such as currency conversion ratios.
+// Potentials are equal when they are within a relative difference of Epsilon
+// to allow for floating point rounding. Potentials must not be zero.
+type MultiplicativeGroup struct {
+	Epsilon float64
+}
+
+// Identity implements Group
+func (MultiplicativeGroup) Identity() float64 { return 1 }
+
+// Combine implements Group
+func (MultiplicativeGroup) Combine(a, b float64) float64 { return a * b }
+
+// Inverse implements Group
+func (MultiplicativeGroup) Inverse(a float64) float64 { return 1 / a }
+
+// Equal implements Group
+func (g MultiplicativeGroup) Equal(a, b float64) bool {
+	return math.Abs(a-b) <= g.Epsilon*max(math.Abs(a), math.Abs(b))
+}
+
+// XORGroup combines potentials with exclusive or, such as the parity of paths between elements
+type XORGroup[N integer] struct{}
+
+// Identity implements Group
+func (XORGroup[N]) Identity() N { return 0 }
+
+// Combine implements Group
+func (XORGroup[N]) Combine(a, b N) N { return a ^ b }
+
+// Inverse implements Group
+func (XORGroup[N]) Inverse(a N) N { return a }
+
+// Equal implements Group
+func (XORGroup[N]) Equal(a, b N) bool { return a == b }
+
+// NewWeightedUnionFind creates a new WeightedUnionFind with the specified capacity
+// combining potentials with the given group
+func NewWeightedUnionFind[W any](capacity int, group Group[W]) *WeightedUnionFind[W] {
+	return &WeightedUnionFind[W]{
+		Root:        make([]int, capacity),
+		Initialized: make([]bool, capacity),
+		Rank:        make([]int, capacity),
+		Potential:   make([]W, capacity),
+		group:       group,
+	}
+}
+
+func (uf *WeightedUnionFind[W]) addElement(n int) {
+	if n >= len(uf.Root) {
+		uf.Root = expandSlice(uf.Root, n)
+		uf.Initialized = expandSlice(uf.Initialized, n)
+		uf.Rank = expandSlice(uf.Rank, n)
+		uf.Potential = expandSlice(uf.Potential, n)
+	}
+
+	if !uf.Initialized[n] {
+		uf.Root[n] = n
+		uf.Initialized[n] = true
+		uf.Rank[n] = 1
+		uf.Potential[n] = uf.group.Identity()
+		uf.RootCount++
+	}
+}
+
+// Contains reports whether the element at the given index has been added
+func (uf *WeightedUnionFind[W]) Contains(index int) bool {
+	return index >= 0 && index < len(uf.Initialized) && uf.Initialized[index]
+}
+
+// Find returns the root of the set containing the given index
+func (uf *WeightedUnionFind[W]) Find(index int) int {
+	root, _ := uf.findWithPotential(index)
+	return root
+}
+
+// findWithPotential returns the root of the set containing the given index
+// and the potential of index relative to the root.
+// Paths are compressed, combining the potentials along the way.
+func (uf *WeightedUnionFind[W]) findWithPotential(index int) (int, W) {
+	uf.addElement(index)
+
+	root := index
+	for uf.Root[root] != root {
+		root = uf.Root[root]
+	}
+
+	// The potential relative to the root of every element on the path
+	// is the potential of its parent combined with its own, so work back from the root
+	var path []int
+	for i := index; uf.Root[i] != root && i != root; i = uf.Root[i] {
+		path = append(path, i)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		element := path[i]
+		parent := uf.Root[element]
+		uf.Potential[element] = uf.group.Combine(uf.Potential[parent], uf.Potential[element])
+		uf.Root[element] = root
+	}
+
+	if index == root {
+		return root, uf.group.Identity()
+	}
+
+	return root, uf.Potential[index]
+}
+
+// Union records that b's potential is a's combined with w, merging their sets.
+// It returns the root of the merged set, and false without changing anything
+// if a and b are already in the same set with a different relative potential.
+func (uf *WeightedUnionFind[W]) Union(a, b int, w W) (int, bool) {
+	rootA, potentialA := uf.findWithPotential(a)
+	rootB, potentialB := uf.findWithPotential(b)
+
+	if rootA == rootB {
+		return rootA, uf.group.Equal(potentialB, uf.group.Combine(potentialA, w))
+	}
+
+	// Potential of rootB relative to rootA so that b ends up relative to a by w
+	rootBFromA := uf.group.Combine(uf.group.Combine(potentialA, w), uf.group.Inverse(potentialB))
+
+	if uf.Rank[rootA] < uf.Rank[rootB] {
+		uf.Root[rootA] = rootB
+		uf.Potential[rootA] = uf.group.Inverse(rootBFromA)
+		uf.Rank[rootB] += uf.Rank[rootA]
+
+		return rootB, true
+	}
+
+	uf.Root[rootB] = rootA
+	uf.Potential[rootB] = rootBFromA
+	uf.Rank[rootA] += uf.Rank[rootB]
+
+	return rootA, true
+}
+
+// Diff returns the potential of b relative to a,
+// and false if they are not in the same set.
+// Elements that have not been added are not added by the check.
+func (uf *WeightedUnionFind[W]) Diff(a, b int) (W, bool) {
+	if !uf.Contains(a) || !uf.Contains(b) {
+		return uf.group.Identity(), false
+	}
+
+	rootA, potentialA := uf.findWithPotential(a)
+	rootB, potentialB := uf.findWithPotential(b)
+	if rootA != rootB {
+		return uf.group.Identity(), false
+	}
+
+	return uf.group.Combine(uf.group.Inverse(potentialA), potentialB), true
+}
+
+// Connected reports whether a and b are in the same set.
+// Elements that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *WeightedUnionFind[W]) Connected(a, b int) bool {
+	_, ok := uf.Diff(a, b)
+	return ok
+}
+
+// WeightedUnionFindWithValues represents a weighted union-find structure with generic values
+type WeightedUnionFindWithValues[T comparable, W any] struct {
+	*WeightedUnionFind[W]
+	values *EnumeratedValues[T]
+}
+
+// NewWeightedUnionFindWithValues creates a new WeightedUnionFindWithValues with the specified capacity
+// combining potentials with the given group
+func NewWeightedUnionFindWithValues[T comparable, W any](capacity int, group Group[W]) *WeightedUnionFindWithValues[T, W] {
+	return &WeightedUnionFindWithValues[T, W]{
+		WeightedUnionFind: NewWeightedUnionFind(capacity, group),
+		values:            NewEnumeratedValues[T](capacity),
+	}
+}
+
+// FindReturningValue returns the root value of the set containing the given value
+func (uf *WeightedUnionFindWithValues[T, W]) FindReturningValue(value T) T {
+	return uf.values.At(uf.WeightedUnionFind.Find(uf.values.FetchIndex(value)))
+}
+
+// Union records that b's potential is a's combined with w, merging their sets.
+// It returns the root value of the merged set, and false without changing anything
+// if a and b are already in the same set with a different relative potential.
+func (uf *WeightedUnionFindWithValues[T, W]) Union(a, b T, w W) (T, bool) {
+	root, ok := uf.WeightedUnionFind.Union(uf.values.FetchIndex(a), uf.values.FetchIndex(b), w)
+	return uf.values.At(root), ok
+}
+
+// Diff returns the potential of b relative to a,
+// and false if they are not in the same set.
+// Values that have not been added are not added by the check.
+func (uf *WeightedUnionFindWithValues[T, W]) Diff(a, b T) (W, bool) {
+	indexA, okA := uf.values.Lookup(a)
+	indexB, okB := uf.values.Lookup(b)
+	if !okA || !okB {
+		return uf.group.Identity(), false
+	}
+
+	return uf.WeightedUnionFind.Diff(indexA, indexB)
+}
+
+// Connected reports whether values a and b are in the same set.
+// Values that have not been added are not connected to anything
+// and are not added by the check.
+func (uf *WeightedUnionFindWithValues[T, W]) Connected(a, b T) bool {
+	_, ok := uf.Diff(a, b)
+	return ok
+}
@@ -0,0 +1,143 @@
+package unionfind_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/maxjustus/bpuf/unionfind"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightedUnionFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("additive potentials", func(t *testing.T) {
+		uf := unionfind.NewWeightedUnionFind[int](0, unionfind.AdditiveGroup[int]{})
+
+		_, ok := uf.Union(0, 1, 5)
+		assert.True(t, ok)
+		_, ok = uf.Union(2, 1, -3)
+		assert.True(t, ok)
+		_, ok = uf.Union(3, 4, 10)
+		assert.True(t, ok)
+
+		diff, ok := uf.Diff(0, 2)
+		require.True(t, ok)
+		assert.Equal(t, 8, diff)
+
+		diff, ok = uf.Diff(2, 0)
+		require.True(t, ok)
+		assert.Equal(t, -8, diff)
+
+		diff, ok = uf.Diff(1, 1)
+		require.True(t, ok)
+		assert.Equal(t, 0, diff)
+
+		_, ok = uf.Diff(0, 3)
+		assert.False(t, ok)
+		assert.False(t, uf.Connected(0, 3))
+
+		_, ok = uf.Union(2, 4, 0)
+		assert.True(t, ok)
+		diff, ok = uf.Diff(0, 3)
+		require.True(t, ok)
+		assert.Equal(t, -2, diff)
+		assert.Equal(t, 5, uf.RootCount)
+	})
+
+	t.Run("contradictions are reported and ignored", func(t *testing.T) {
+		uf := unionfind.NewWeightedUnionFind[int](0, unionfind.AdditiveGroup[int]{})
+		uf.Union(0, 1, 5)
+		uf.Union(1, 2, 3)
+
+		_, ok := uf.Union(0, 2, 8)
+		assert.True(t, ok)
+
+		_, ok = uf.Union(0, 2, 7)
+		assert.False(t, ok)
+
+		diff, ok := uf.Diff(0, 2)
+		require.True(t, ok)
+		assert.Equal(t, 8, diff)
+	})
+
+	t.Run("Diff does not add elements", func(t *testing.T) {
+		uf := unionfind.NewWeightedUnionFind[int](0, unionfind.AdditiveGroup[int]{})
+		uf.Union(0, 1, 1)
+
+		_, ok := uf.Diff(0, 7)
+		assert.False(t, ok)
+		assert.False(t, uf.Contains(7))
+		assert.Equal(t, 2, uf.RootCount)
+	})
+
+	t.Run("multiplicative potentials", func(t *testing.T) {
+		group := unionfind.MultiplicativeGroup{Epsilon: 1e-9}
+		uf := unionfind.NewWeightedUnionFindWithValues[string, float64](0, group)
+
+		_, ok := uf.Union("USD", "EUR", 0.9)
+		assert.True(t, ok)
+		_, ok = uf.Union("EUR", "JPY", 160)
+		assert.True(t, ok)
+
+		rate, ok := uf.Diff("USD", "JPY")
+		require.True(t, ok)
+		assert.InDelta(t, 144, rate, 1e-9)
+
+		rate, ok = uf.Diff("JPY", "EUR")
+		require.True(t, ok)
+		assert.InDelta(t, 1.0/160, rate, 1e-12)
+
+		_, ok = uf.Union("JPY", "USD", 1.0/144)
+		assert.True(t, ok, "rounding within epsilon is not a contradiction")
+		_, ok = uf.Union("JPY", "USD", 1.0/140)
+		assert.False(t, ok)
+
+		_, ok = uf.Diff("USD", "GBP")
+		assert.False(t, ok)
+		assert.False(t, uf.Connected("USD", "GBP"))
+		assert.True(t, uf.Connected("USD", "JPY"))
+	})
+
+	t.Run("XOR potentials", func(t *testing.T) {
+		uf := unionfind.NewWeightedUnionFind[uint8](0, unionfind.XORGroup[uint8]{})
+
+		// Odd cycles can't be two-coloured
+		uf.Union(0, 1, 1)
+		uf.Union(1, 2, 1)
+		_, ok := uf.Union(2, 0, 0)
+		assert.True(t, ok)
+		_, ok = uf.Union(2, 0, 1)
+		assert.False(t, ok)
+
+		parity, ok := uf.Diff(0, 2)
+		require.True(t, ok)
+		assert.Equal(t, uint8(0), parity)
+	})
+
+	t.Run("potentials survive path compression", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1)) //nolint:gosec // test code using weak random is acceptable
+		const n = 500
+
+		values := make([]int, n)
+		for i := range values {
+			values[i] = rng.Intn(1000)
+		}
+
+		uf := unionfind.NewWeightedUnionFind[int](0, unionfind.AdditiveGroup[int]{})
+		for range 2 * n {
+			a, b := rng.Intn(n), rng.Intn(n)
+			_, ok := uf.Union(a, b, values[b]-values[a])
+			require.True(t, ok)
+		}
+
+		for range 2 * n {
+			a, b := rng.Intn(n), rng.Intn(n)
+			if diff, ok := uf.Diff(a, b); ok {
+				assert.Equal(t, values[b]-values[a], diff)
+			}
+		}
+	})
+}